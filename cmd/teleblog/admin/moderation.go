@@ -0,0 +1,1095 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"gopkg.in/telebot.v4"
+)
+
+const moderationPerPage = 20
+
+type moderationActionRequest struct {
+	Ids    []string `json:"ids"`
+	Action string   `json:"action"`
+}
+
+type moderationPost struct {
+	Id             string   `json:"id"`
+	ChatTitle      string   `json:"chatTitle"`
+	Title          string   `json:"title"`
+	Slug           string   `json:"slug"`
+	SeoDescription string   `json:"seoDescription"`
+	Preview        string   `json:"preview"`
+	Error          string   `json:"error"`
+	Media          []string `json:"media"`
+	Tags           []string `json:"tags"`
+	Url            string   `json:"url"`
+	Created        string   `json:"created"`
+	Hidden         bool     `json:"hidden"`
+	Unparsable     bool     `json:"unparsable"`
+}
+
+type moderationComment struct {
+	Id        string  `json:"id"`
+	Author    string  `json:"author"`
+	Text      string  `json:"text"`
+	PostTitle string  `json:"postTitle"`
+	PostUrl   string  `json:"postUrl"`
+	Created   string  `json:"created"`
+	Hidden    bool    `json:"hidden"`
+	Filtered  bool    `json:"filtered"`
+	Spam      bool    `json:"spam"`
+	SpamLabel string  `json:"spamLabel"`
+	SpamScore float64 `json:"spamScore"`
+}
+
+// moderationPage returns current page number of the list
+func moderationPage(c echo.Context) int64 {
+	page, err := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	if err != nil || page < 1 {
+		return 1
+	}
+
+	return page
+}
+
+// moderationPostsQuery selects posts matching filters of the dashboard
+func moderationPostsQuery(app *pocketbase.PocketBase, c echo.Context) *dbx.SelectQuery {
+	query := teleblog.PostQuery(app.Dao())
+
+	if chatId := c.QueryParam("chat_id"); chatId != "" {
+		query = query.AndWhere(dbx.HashExp{"post.chat_id": chatId})
+	}
+
+	if tag := c.QueryParam("tag"); tag != "" {
+		query = query.
+			InnerJoin("post_tag", dbx.NewExp("post_tag.post_id = post.id")).
+			InnerJoin("tag", dbx.NewExp("tag.id = post_tag.tag_id")).
+			AndWhere(dbx.HashExp{"tag.value": strings.TrimPrefix(tag, "#")})
+	}
+
+	if c.QueryParam("unparsable") == "true" {
+		query = query.AndWhere(dbx.HashExp{"post.unparsable": true})
+	}
+
+	if c.QueryParam("hidden") == "true" {
+		query = query.AndWhere(dbx.HashExp{"post.hidden": true})
+	}
+
+	if search := c.QueryParam("search"); search != "" {
+		query = query.AndWhere(dbx.Like("post.text", search))
+	}
+
+	return query
+}
+
+// moderationCommentsQuery selects comments matching filters of the dashboard
+func moderationCommentsQuery(app *pocketbase.PocketBase, c echo.Context) *dbx.SelectQuery {
+	query := teleblog.CommentQuery(app.Dao()).
+		LeftJoin("post", dbx.NewExp("post.id = comment.post_id"))
+
+	// # Both channel and its discussion group show comments of the channel
+	if chatId := c.QueryParam("chat_id"); chatId != "" {
+		query = query.AndWhere(dbx.Or(
+			dbx.HashExp{"comment.chat_id": chatId},
+			dbx.HashExp{"post.chat_id": chatId},
+		))
+	}
+
+	if c.QueryParam("hidden") == "true" {
+		query = query.AndWhere(dbx.NewExp("comment.hidden = true OR comment.filtered = true"))
+	}
+
+	if c.QueryParam("spam") == "true" {
+		query = query.AndWhere(dbx.HashExp{"comment.spam": true})
+	}
+
+	if search := c.QueryParam("search"); search != "" {
+		query = query.AndWhere(dbx.Like("comment.text", search))
+	}
+
+	return query
+}
+
+// commentAuthorTitle returns name of the comment author from its raw message
+func commentAuthorTitle(comment *teleblog.Comment) string {
+	jb, err := comment.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+
+	if comment.IsTgHistoryMessage {
+		rawMessage := teleblog.HistoryMessage{}
+
+		if err := json.Unmarshal(jb, &rawMessage); err != nil {
+			return ""
+		}
+
+		return rawMessage.From
+	}
+
+	rawMessage := telebot.Message{}
+
+	if err := json.Unmarshal(jb, &rawMessage); err != nil || rawMessage.Sender == nil {
+		return ""
+	}
+
+	if rawMessage.SenderChat != nil {
+		return rawMessage.SenderChat.Title
+	}
+
+	return strings.TrimSpace(rawMessage.Sender.FirstName + " " + rawMessage.Sender.LastName)
+}
+
+// InitModerationUI initializes admin UI routes to moderate posts and comments
+func InitModerationUI(app *pocketbase.PocketBase, e *core.ServeEvent) error {
+	e.Router.GET("/_/moderation", func(c echo.Context) error {
+		html := `
+				<!DOCTYPE html>
+				<html lang="ru">
+				<head>
+					<title>Модерация</title>
+					<meta charset="utf-8">
+					<meta name="viewport" content="width=device-width, initial-scale=1.0">
+					<link rel="stylesheet" href="https://fonts.googleapis.com/css2?family=Inter:wght@400;500;600&display=swap">
+					<style>
+						:root {
+							--primary-color: #000000;
+							--primary-hover: #333333;
+							--success-color: #0070f3;
+							--error-color: #ff0000;
+							--bg-color: #fafafa;
+							--card-bg: #ffffff;
+							--text-color: #000000;
+							--text-secondary: #666666;
+							--border-color: #eaeaea;
+							--border-hover: #000000;
+						}
+
+						* {
+							margin: 0;
+							padding: 0;
+							box-sizing: border-box;
+						}
+
+						body {
+							font-family: 'Inter', -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif;
+							background-color: var(--bg-color);
+							color: var(--text-color);
+							line-height: 1.5;
+							-webkit-font-smoothing: antialiased;
+						}
+
+						.container {
+							max-width: 1000px;
+							margin: 40px auto;
+							padding: 0 20px;
+						}
+
+						h1 {
+							font-size: 28px;
+							font-weight: 600;
+							margin-bottom: 24px;
+							letter-spacing: -0.02em;
+						}
+
+						.tabs {
+							display: flex;
+							gap: 8px;
+							margin-bottom: 16px;
+						}
+
+						.toolbar {
+							display: flex;
+							flex-wrap: wrap;
+							align-items: center;
+							gap: 8px;
+							margin-bottom: 16px;
+						}
+
+						input[type="text"], select {
+							height: 36px;
+							padding: 0 10px;
+							border: 1px solid var(--border-color);
+							border-radius: 5px;
+							font-size: 14px;
+							background: var(--card-bg);
+						}
+
+						label.check {
+							display: flex;
+							align-items: center;
+							gap: 6px;
+							font-size: 14px;
+						}
+
+						button {
+							background: var(--primary-color);
+							color: white;
+							border: none;
+							padding: 0 16px;
+							height: 36px;
+							border-radius: 5px;
+							font-weight: 500;
+							font-size: 14px;
+							cursor: pointer;
+							transition: all 0.15s ease;
+						}
+
+						button:hover {
+							background: var(--primary-hover);
+						}
+
+						button.secondary {
+							background: var(--card-bg);
+							color: var(--text-color);
+							border: 1px solid var(--border-color);
+						}
+
+						button:disabled {
+							opacity: 0.5;
+							cursor: not-allowed;
+						}
+
+						.card {
+							display: flex;
+							gap: 16px;
+							background: var(--card-bg);
+							border-radius: 5px;
+							border: 1px solid var(--border-color);
+							padding: 16px;
+							margin-bottom: 12px;
+						}
+
+						.card:hover {
+							border-color: var(--border-hover);
+						}
+
+						.card-body {
+							flex: 1;
+							min-width: 0;
+						}
+
+						.meta {
+							color: var(--text-secondary);
+							font-size: 13px;
+							margin-bottom: 8px;
+						}
+
+						.badge {
+							display: inline-block;
+							padding: 0 6px;
+							margin-left: 4px;
+							border-radius: 3px;
+							font-size: 12px;
+							border: 1px solid var(--border-color);
+						}
+
+						.badge-error {
+							color: var(--error-color);
+							border-color: var(--error-color);
+						}
+
+						.preview {
+							font-size: 14px;
+							max-height: 160px;
+							overflow: hidden;
+							word-break: break-word;
+						}
+
+						.preview img {
+							max-width: 100%;
+						}
+
+						.thumbs img {
+							width: 64px;
+							height: 64px;
+							object-fit: cover;
+							border-radius: 3px;
+							margin: 8px 4px 0 0;
+						}
+
+						.edit-form {
+							display: none;
+							flex-direction: column;
+							gap: 8px;
+							margin-top: 12px;
+						}
+
+						.edit-form.opened {
+							display: flex;
+						}
+
+						.pagination {
+							display: flex;
+							align-items: center;
+							justify-content: center;
+							gap: 12px;
+							margin: 24px 0;
+							font-size: 14px;
+						}
+
+						.alert {
+							padding: 16px;
+							margin-bottom: 16px;
+							border-radius: 5px;
+							font-size: 14px;
+							background: var(--bg-color);
+							border: 1px solid var(--border-color);
+						}
+
+						.alert-success {
+							border-left: 4px solid var(--success-color);
+						}
+
+						.alert-error {
+							border-left: 4px solid var(--error-color);
+						}
+					</style>
+				</head>
+				<body>
+					<div class="container">
+						<h1>Модерация</h1>
+						<div class="tabs">
+							<button id="postsTab">Посты</button>
+							<button id="commentsTab" class="secondary">Комментарии</button>
+						</div>
+						<div class="toolbar">
+							<select id="chatFilter"><option value="">Все каналы</option></select>
+							<input type="text" id="tagFilter" placeholder="Тег">
+							<input type="text" id="searchFilter" placeholder="Поиск по тексту">
+							<label class="check posts-only"><input type="checkbox" id="unparsableFilter"> Нераспознанные</label>
+							<label class="check"><input type="checkbox" id="hiddenFilter"> Скрытые</label>
+							<label class="check comments-only"><input type="checkbox" id="spamFilter"> Спам</label>
+							<button id="applyFilters" class="secondary">Найти</button>
+						</div>
+						<div class="toolbar">
+							<label class="check"><input type="checkbox" id="selectAll"> Выбрать все</label>
+							<select id="bulkAction"></select>
+							<button id="applyAction">Применить</button>
+						</div>
+						<div id="result"></div>
+						<div id="report"></div>
+						<div id="list"></div>
+						<div class="pagination">
+							<button id="prevPage" class="secondary">Назад</button>
+							<span id="pageInfo"></span>
+							<button id="nextPage" class="secondary">Вперед</button>
+						</div>
+					</div>
+
+					<script>
+						// Get admin token from localStorage
+						const token = JSON.parse(localStorage.getItem('pb_admin_auth')).token;
+
+						const actions = {
+							posts: [
+								['hide', 'Скрыть'],
+								['show', 'Показать'],
+								['reparse', 'Распознать заново'],
+								['retag', 'Обновить теги'],
+								['delete', 'Удалить'],
+							],
+							comments: [
+								['hide', 'Скрыть'],
+								['show', 'Показать'],
+								['spam', 'Это спам'],
+								['ham', 'Не спам'],
+								['delete', 'Удалить'],
+							],
+						};
+
+						const state = {
+							tab: 'posts',
+							page: 1,
+							total: 0,
+						};
+
+						const list = document.getElementById('list');
+						const result = document.getElementById('result');
+
+						function esc(value) {
+							const div = document.createElement('div');
+							div.textContent = value == null ? '' : String(value);
+							return div.innerHTML;
+						}
+
+						function showResult(ok, message) {
+							result.innerHTML = '<div class="alert ' + (ok ? 'alert-success' : 'alert-error') + '">' + esc(message) + '</div>';
+						}
+
+						async function request(url, options = {}) {
+							const response = await fetch(url, {
+								...options,
+								headers: {
+									'Authorization': token,
+									'Content-Type': 'application/json',
+								},
+							});
+
+							const data = await response.json();
+
+							if (!response.ok) {
+								throw new Error(data.error || data.message || response.statusText);
+							}
+
+							return data;
+						}
+
+						function filtersQuery() {
+							const params = new URLSearchParams({
+								page: state.page,
+								chat_id: document.getElementById('chatFilter').value,
+								search: document.getElementById('searchFilter').value,
+								hidden: document.getElementById('hiddenFilter').checked,
+							});
+
+							if (state.tab === 'posts') {
+								params.set('tag', document.getElementById('tagFilter').value);
+								params.set('unparsable', document.getElementById('unparsableFilter').checked);
+							} else {
+								params.set('spam', document.getElementById('spamFilter').checked);
+							}
+
+							return params.toString();
+						}
+
+						function renderPost(post) {
+							const badges = [];
+							if (post.hidden) badges.push('<span class="badge">скрыт</span>');
+							if (post.unparsable) badges.push('<span class="badge badge-error">не распознан</span>');
+
+							const thumbs = post.media.map(url => '<img src="' + esc(url) + '" loading="lazy">').join('');
+							const tags = post.tags.map(tag => '#' + esc(tag)).join(' ');
+
+							return '<div class="card">' +
+								'<input type="checkbox" class="select" value="' + esc(post.id) + '">' +
+								'<div class="card-body">' +
+									'<div class="meta">' + esc(post.chatTitle) + ' · ' + esc(post.created) + ' · <a href="' + esc(post.url) + '" target="_blank">' + esc(post.title || post.id) + '</a>' + badges.join('') + '</div>' +
+									(post.error ? '<div class="meta badge-error">' + esc(post.error) + '</div>' : '') +
+									'<div class="preview">' + post.preview + '</div>' +
+									'<div class="thumbs">' + thumbs + '</div>' +
+									'<div class="meta">' + tags + '</div>' +
+									'<button class="secondary edit-toggle">Редактировать</button>' +
+									'<form class="edit-form" data-id="' + esc(post.id) + '">' +
+										'<input type="text" name="title" placeholder="Заголовок" value="' + esc(post.title) + '">' +
+										'<input type="text" name="slug" placeholder="Slug" value="' + esc(post.slug) + '">' +
+										'<input type="text" name="seo_description" placeholder="SEO описание" value="' + esc(post.seoDescription) + '">' +
+										'<button type="submit">Сохранить</button>' +
+									'</form>' +
+								'</div>' +
+							'</div>';
+						}
+
+						function renderComment(comment) {
+							const badges = [];
+							if (comment.hidden) badges.push('<span class="badge">скрыт</span>');
+							if (comment.filtered) badges.push('<span class="badge">отфильтрован</span>');
+							if (comment.spam) badges.push('<span class="badge badge-error">спам</span>');
+							if (comment.spamLabel) badges.push('<span class="badge">метка: ' + esc(comment.spamLabel) + '</span>');
+
+							return '<div class="card">' +
+								'<input type="checkbox" class="select" value="' + esc(comment.id) + '">' +
+								'<div class="card-body">' +
+									'<div class="meta">' + esc(comment.author) + ' · ' + esc(comment.created) + ' · <a href="' + esc(comment.postUrl) + '" target="_blank">' + esc(comment.postTitle) + '</a> · спам ' + comment.spamScore.toFixed(2) + badges.join('') + '</div>' +
+									'<div class="preview">' + esc(comment.text).replace(/\n/g, '<br>') + '</div>' +
+								'</div>' +
+							'</div>';
+						}
+
+						async function loadReport() {
+							const report = document.getElementById('report');
+
+							if (state.tab !== 'posts') {
+								report.innerHTML = '';
+								return;
+							}
+
+							const groups = await request('/_/moderation/unparsable');
+
+							if (groups.length === 0) {
+								report.innerHTML = '';
+								return;
+							}
+
+							const rows = groups.map(group => '<li>' + group.count + ' – ' + esc(group.reason || 'причина не сохранена') + '</li>').join('');
+
+							report.innerHTML = '<div class="alert alert-error"><div>Нераспознанные посты по ошибкам:<ul>' + rows + '</ul></div><br><button id="reparseAll" class="secondary">Распознать все заново</button></div>';
+
+							document.getElementById('reparseAll').addEventListener('click', async (e) => {
+								e.target.disabled = true;
+
+								try {
+									const data = await request('/_/moderation/unparsable/reparse', { method: 'POST' });
+									showResult(true, data.message);
+									load();
+								} catch (error) {
+									showResult(false, error.message);
+								}
+							});
+						}
+
+						async function load() {
+							loadReport().catch(error => showResult(false, error.message));
+
+							document.getElementById('selectAll').checked = false;
+
+							try {
+								const data = await request('/_/moderation/' + state.tab + '?' + filtersQuery());
+
+								state.total = data.total;
+
+								list.innerHTML = data.items.length === 0
+									? '<div class="meta">Ничего не найдено</div>'
+									: data.items.map(state.tab === 'posts' ? renderPost : renderComment).join('');
+
+								const pages = Math.max(1, Math.ceil(data.total / data.perPage));
+								document.getElementById('pageInfo').textContent = state.page + ' / ' + pages;
+								document.getElementById('prevPage').disabled = state.page <= 1;
+								document.getElementById('nextPage').disabled = state.page >= pages;
+							} catch (error) {
+								showResult(false, error.message);
+							}
+						}
+
+						function switchTab(tab) {
+							state.tab = tab;
+							state.page = 1;
+							result.innerHTML = '';
+
+							document.getElementById('postsTab').className = tab === 'posts' ? '' : 'secondary';
+							document.getElementById('commentsTab').className = tab === 'comments' ? '' : 'secondary';
+
+							document.querySelectorAll('.posts-only').forEach(el => el.style.display = tab === 'posts' ? '' : 'none');
+							document.querySelectorAll('.comments-only').forEach(el => el.style.display = tab === 'comments' ? '' : 'none');
+							document.getElementById('tagFilter').style.display = tab === 'posts' ? '' : 'none';
+
+							document.getElementById('bulkAction').innerHTML = actions[tab]
+								.map(([value, name]) => '<option value="' + value + '">' + name + '</option>')
+								.join('');
+
+							load();
+						}
+
+						document.getElementById('postsTab').addEventListener('click', () => switchTab('posts'));
+						document.getElementById('commentsTab').addEventListener('click', () => switchTab('comments'));
+
+						document.getElementById('applyFilters').addEventListener('click', () => {
+							state.page = 1;
+							load();
+						});
+
+						document.getElementById('prevPage').addEventListener('click', () => {
+							state.page--;
+							load();
+						});
+
+						document.getElementById('nextPage').addEventListener('click', () => {
+							state.page++;
+							load();
+						});
+
+						document.getElementById('selectAll').addEventListener('change', (e) => {
+							list.querySelectorAll('.select').forEach(el => el.checked = e.target.checked);
+						});
+
+						document.getElementById('applyAction').addEventListener('click', async (e) => {
+							const ids = Array.from(list.querySelectorAll('.select:checked')).map(el => el.value);
+							const action = document.getElementById('bulkAction').value;
+
+							if (ids.length === 0) {
+								showResult(false, 'Ничего не выбрано');
+								return;
+							}
+
+							if (action === 'delete' && !confirm('Удалить выбранное (' + ids.length + ')?')) {
+								return;
+							}
+
+							e.target.disabled = true;
+
+							try {
+								const data = await request('/_/moderation/' + state.tab + '/action', {
+									method: 'POST',
+									body: JSON.stringify({ ids, action }),
+								});
+
+								if (data.errors && data.errors.length > 0) {
+									showResult(false, data.message + ': ' + data.errors.join('; '));
+								} else {
+									showResult(true, data.message);
+								}
+
+								load();
+							} catch (error) {
+								showResult(false, error.message);
+							} finally {
+								e.target.disabled = false;
+							}
+						});
+
+						list.addEventListener('click', (e) => {
+							if (e.target.classList.contains('edit-toggle')) {
+								e.target.nextElementSibling.classList.toggle('opened');
+							}
+						});
+
+						list.addEventListener('submit', async (e) => {
+							e.preventDefault();
+
+							const form = e.target;
+
+							try {
+								const data = await request('/_/moderation/posts/' + form.dataset.id, {
+									method: 'POST',
+									body: JSON.stringify({
+										title: form.elements.title.value,
+										slug: form.elements.slug.value,
+										seo_description: form.elements.seo_description.value,
+									}),
+								});
+
+								showResult(true, data.message);
+								load();
+							} catch (error) {
+								showResult(false, error.message);
+							}
+						});
+
+						request('/_/moderation/chats').then(chats => {
+							const select = document.getElementById('chatFilter');
+
+							chats.forEach(chat => {
+								const option = document.createElement('option');
+								option.value = chat.id;
+								option.textContent = chat.title;
+								select.appendChild(option);
+							});
+						});
+
+						switchTab('posts');
+					</script>
+				</body>
+				</html>
+			`
+		return c.HTML(http.StatusOK, html)
+	})
+
+	// Chats to filter posts and comments
+	e.Router.GET("/_/moderation/chats", func(c echo.Context) error {
+		chats := []*teleblog.Chat{}
+
+		err := teleblog.ChatQuery(app.Dao()).OrderBy("tg_title asc").All(&chats)
+		if err != nil {
+			return err
+		}
+
+		result := []map[string]string{}
+
+		for _, chat := range chats {
+			title := chat.TgTitle
+			if chat.TgUsername != "" {
+				title += " (@" + chat.TgUsername + ")"
+			}
+
+			result = append(result, map[string]string{
+				"id":    chat.Id,
+				"title": title,
+			})
+		}
+
+		return c.JSON(http.StatusOK, result)
+	}, apis.RequireAdminAuth())
+
+	// Posts with rendered previews
+	e.Router.GET("/_/moderation/posts", func(c echo.Context) error {
+		page := moderationPage(c)
+
+		var total int64
+
+		err := moderationPostsQuery(app, c).Select("count(post.id)").Row(&total)
+		if err != nil {
+			return fmt.Errorf("Moderation: count posts error: %w", err)
+		}
+
+		posts := []*teleblog.Post{}
+
+		err = moderationPostsQuery(app, c).
+			OrderBy("post.created desc").
+			Limit(moderationPerPage).
+			Offset((page - 1) * moderationPerPage).
+			All(&posts)
+		if err != nil {
+			return fmt.Errorf("Moderation: get posts error: %w", err)
+		}
+
+		postCollection, err := app.Dao().FindCollectionByNameOrId("post")
+		if err != nil {
+			return err
+		}
+
+		// # Channel titles
+		chats := []*teleblog.Chat{}
+
+		err = teleblog.ChatQuery(app.Dao()).All(&chats)
+		if err != nil {
+			return err
+		}
+
+		chatTitles := map[string]string{}
+		for _, chat := range chats {
+			chatTitles[chat.Id] = chat.TgTitle
+		}
+
+		// # Tags
+		postIds := []any{}
+		for _, post := range posts {
+			postIds = append(postIds, post.Id)
+		}
+
+		postTags := []struct {
+			PostId string `db:"post_id"`
+			Value  string `db:"value"`
+		}{}
+
+		err = app.Dao().DB().
+			Select("post_tag.post_id", "tag.value").
+			From("post_tag").
+			InnerJoin("tag", dbx.NewExp("tag.id = post_tag.tag_id")).
+			Where(dbx.In("post_tag.post_id", postIds...)).
+			All(&postTags)
+		if err != nil {
+			return fmt.Errorf("Moderation: get tags error: %w", err)
+		}
+
+		tagsByPost := map[string][]string{}
+		for _, postTag := range postTags {
+			tagsByPost[postTag.PostId] = append(tagsByPost[postTag.PostId], postTag.Value)
+		}
+
+		items := []moderationPost{}
+
+		for _, post := range posts {
+			item := moderationPost{
+				Id:             post.Id,
+				ChatTitle:      chatTitles[post.ChatId],
+				Title:          post.Title,
+				Slug:           post.Slug,
+				SeoDescription: post.SeoDescription,
+				Media:          []string{},
+				Tags:           tagsByPost[post.Id],
+				Url:            teleblog.PostPath(*post),
+				Created:        post.Created.Time().Format("2006-01-02 15:04"),
+				Hidden:         post.Hidden,
+				Unparsable:     post.Unparsable,
+			}
+
+			if item.Tags == nil {
+				item.Tags = []string{}
+			}
+
+			if post.Unparsable {
+				item.Error = post.UnparsableReason
+			}
+
+			item.Preview, err = teleblog.PostTextWithMarkup(*post)
+			if err != nil {
+				item.Error = err.Error()
+			}
+
+			if item.Preview == "" {
+				item.Preview = strings.ReplaceAll(html.EscapeString(post.Text), "\n", "<br>")
+			}
+
+			if post.Cover != "" {
+				item.Media = append(item.Media, "/api/files/"+postCollection.Id+"/"+post.Id+"/"+post.Cover)
+			}
+
+			for _, media := range post.Media {
+				item.Media = append(item.Media, "/api/files/"+postCollection.Id+"/"+post.Id+"/"+media)
+			}
+
+			items = append(items, item)
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"items":   items,
+			"total":   total,
+			"perPage": moderationPerPage,
+		})
+	}, apis.RequireAdminAuth())
+
+	// Bulk actions with posts
+	e.Router.POST("/_/moderation/posts/action", func(c echo.Context) error {
+		data := moderationActionRequest{}
+
+		if err := c.Bind(&data); err != nil || len(data.Ids) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Posts are not selected",
+			})
+		}
+
+		if data.Action == "delete" {
+			err := features.DeletePosts(app, data.Ids)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(http.StatusOK, map[string]any{
+				"message": fmt.Sprintf("%d posts deleted", len(data.Ids)),
+			})
+		}
+
+		ids := []any{}
+		for _, id := range data.Ids {
+			ids = append(ids, id)
+		}
+
+		posts := []*teleblog.Post{}
+
+		err := teleblog.PostQuery(app.Dao()).
+			Where(dbx.In("id", ids...)).
+			All(&posts)
+		if err != nil {
+			return err
+		}
+
+		errs := []string{}
+
+		for _, post := range posts {
+			var err error
+
+			switch data.Action {
+			case "hide", "show":
+				// # Album is shown as one post, so it is hidden as a whole
+				albumExp := dbx.HashExp{"id": post.Id}
+				if post.AlbumID != "" {
+					albumExp = dbx.HashExp{"album_id": post.AlbumID}
+				}
+
+				_, err = app.DB().Update(
+					post.TableName(),
+					dbx.Params{"hidden": data.Action == "hide"},
+					dbx.And(
+						dbx.HashExp{"chat_id": post.ChatId},
+						dbx.Or(dbx.HashExp{"id": post.Id}, albumExp),
+					),
+				).Execute()
+			case "reparse":
+				var ok bool
+
+				ok, err = features.ReparsePost(app, post)
+				if err == nil && !ok {
+					err = fmt.Errorf("still can't be parsed: %s", post.UnparsableReason)
+				}
+			case "retag":
+				err = features.ExtractAndSavePostTags(app, *post)
+			default:
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": fmt.Sprintf("Unknown action %q", data.Action),
+				})
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", post.Id, err))
+			}
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"message": fmt.Sprintf("Action is applied to %d posts", len(posts)-len(errs)),
+			"errors":  errs,
+		})
+	}, apis.RequireAdminAuth())
+
+	// Unparsable posts grouped by error
+	e.Router.GET("/_/moderation/unparsable", func(c echo.Context) error {
+		groups, err := features.UnparsablePostsReport(app)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, groups)
+	}, apis.RequireAdminAuth())
+
+	// Parse all unparsable posts again
+	e.Router.POST("/_/moderation/unparsable/reparse", func(c echo.Context) error {
+		fixed, err := features.ReparseUnparsablePosts(app)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("%d posts are parsed and shown on the blog", fixed),
+		})
+	}, apis.RequireAdminAuth())
+
+	// Edit title, slug and SEO of the post
+	e.Router.POST("/_/moderation/posts/:id", func(c echo.Context) error {
+		data := struct {
+			Title          string `json:"title"`
+			Slug           string `json:"slug"`
+			SeoDescription string `json:"seo_description"`
+		}{}
+
+		if err := c.Bind(&data); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Failed to read post: %v", err),
+			})
+		}
+
+		post := &teleblog.Post{}
+
+		err := teleblog.PostQuery(app.Dao()).
+			Where(dbx.HashExp{"id": c.PathParam("id")}).
+			Limit(1).
+			One(post)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": "Post not found",
+				})
+			}
+
+			return err
+		}
+
+		post.Title = strings.TrimSpace(data.Title)
+		post.Slug = strings.TrimSpace(data.Slug)
+		post.SeoDescription = strings.TrimSpace(data.SeoDescription)
+
+		err = app.Dao().Save(post)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Failed to save post: %v", err),
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "Post saved",
+		})
+	}, apis.RequireAdminAuth())
+
+	// Comments with spam scores
+	e.Router.GET("/_/moderation/comments", func(c echo.Context) error {
+		page := moderationPage(c)
+
+		var total int64
+
+		err := moderationCommentsQuery(app, c).Select("count(comment.id)").Row(&total)
+		if err != nil {
+			return fmt.Errorf("Moderation: count comments error: %w", err)
+		}
+
+		comments := []*struct {
+			teleblog.Comment
+			PostTitle string `db:"post_title"`
+			PostSlug  string `db:"post_slug"`
+		}{}
+
+		err = moderationCommentsQuery(app, c).
+			Select("comment.*", "post.title as post_title", "post.slug as post_slug").
+			OrderBy("comment.created desc").
+			Limit(moderationPerPage).
+			Offset((page - 1) * moderationPerPage).
+			All(&comments)
+		if err != nil {
+			return fmt.Errorf("Moderation: get comments error: %w", err)
+		}
+
+		items := []moderationComment{}
+
+		for _, comment := range comments {
+			items = append(items, moderationComment{
+				Id:        comment.Id,
+				Author:    commentAuthorTitle(&comment.Comment),
+				Text:      comment.Text,
+				PostTitle: comment.PostTitle,
+				PostUrl:   teleblog.PostPath(teleblog.Post{BaseModel: models.BaseModel{Id: comment.PostId}, Slug: comment.PostSlug}),
+				Created:   comment.Created.Time().Format("2006-01-02 15:04"),
+				Hidden:    comment.Hidden,
+				Filtered:  comment.Filtered,
+				Spam:      comment.Spam,
+				SpamLabel: comment.SpamLabel,
+				SpamScore: comment.SpamScore,
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"items":   items,
+			"total":   total,
+			"perPage": moderationPerPage,
+		})
+	}, apis.RequireAdminAuth())
+
+	// Bulk actions with comments
+	e.Router.POST("/_/moderation/comments/action", func(c echo.Context) error {
+		data := moderationActionRequest{}
+
+		if err := c.Bind(&data); err != nil || len(data.Ids) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Comments are not selected",
+			})
+		}
+
+		ids := []any{}
+		for _, id := range data.Ids {
+			ids = append(ids, id)
+		}
+
+		comments := []*teleblog.Comment{}
+
+		err := teleblog.CommentQuery(app.Dao()).
+			Where(dbx.In("id", ids...)).
+			All(&comments)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range comments {
+			switch data.Action {
+			case "hide", "show":
+				comment.Hidden = data.Action == "hide"
+			// # Labeled comments train spam classifier
+			case "spam":
+				comment.SpamLabel = teleblog.SPAM_LABEL
+			case "ham":
+				comment.SpamLabel = teleblog.HAM_LABEL
+			case "delete":
+				err = features.DeleteComment(app, comment)
+				if err != nil {
+					return err
+				}
+
+				continue
+			default:
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": fmt.Sprintf("Unknown action %q", data.Action),
+				})
+			}
+
+			err = app.Dao().Save(comment)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"message": fmt.Sprintf("Action is applied to %d comments", len(comments)),
+		})
+	}, apis.RequireAdminAuth())
+
+	return nil
+}
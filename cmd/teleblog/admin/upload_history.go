@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"os"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/Dionid/teleblog/cmd/teleblog/features"
 	"github.com/Dionid/teleblog/libs/file"
+	"github.com/Dionid/teleblog/libs/teleblog"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
@@ -254,6 +256,7 @@ func InitUploadHistoryUI(app *pocketbase.PocketBase, e *core.ServeEvent) error {
 						<div class="card">
 							<h1>Загрузка истории Telegram</h1>
 							<p class="subtitle">Создайте и загрузите сюда Zip файл, с выгрузкой истории чата из Telegram (в формате JSON).</p>
+							<div id="gaps"></div>
 							<form id="uploadForm" enctype="multipart/form-data">
 								<div class="form-group">
 									<label for="historyFile">Файл истории (ZIP)</label>
@@ -286,6 +289,23 @@ func InitUploadHistoryUI(app *pocketbase.PocketBase, e *core.ServeEvent) error {
 						// Get admin token from localStorage
 						const token = JSON.parse(localStorage.getItem('pb_admin_auth')).token;
 
+						// Show posts missed while bot was down
+						fetch('/_/upload-history/gaps', {
+							headers: {
+								'Authorization': token
+							}
+						})
+							.then(response => response.json())
+							.then(chats => {
+								if (!Array.isArray(chats) || chats.length === 0) {
+									return;
+								}
+
+								const items = chats.map(chat => '<li><b>' + chat.title + '</b>: ' + chat.gaps + '</li>').join('');
+
+								document.getElementById('gaps').innerHTML = '<div class="alert alert-error"><div>Пропущенные посты (загрузите выгрузку, которая их содержит, будут добавлены только они):<ul>' + items + '</ul></div></div>';
+							});
+
 						const form = document.getElementById('uploadForm');
 						const dropZone = document.getElementById('dropZone');
 						const fileInput = document.getElementById('historyFile');
@@ -400,6 +420,31 @@ func InitUploadHistoryUI(app *pocketbase.PocketBase, e *core.ServeEvent) error {
 		return c.HTML(http.StatusOK, html)
 	})
 
+	// Posts missed while bot was down
+	e.Router.GET("/_/upload-history/gaps", func(c echo.Context) error {
+		chats := []*teleblog.Chat{}
+
+		err := teleblog.ChatQuery(app.Dao()).All(&chats)
+		if err != nil {
+			return err
+		}
+
+		result := []map[string]string{}
+
+		for _, chat := range chats {
+			if len(chat.TgPostGaps) == 0 {
+				continue
+			}
+
+			result = append(result, map[string]string{
+				"title": html.EscapeString(chat.TgTitle),
+				"gaps":  teleblog.FormatPostGaps(chat.TgPostGaps),
+			})
+		}
+
+		return c.JSON(http.StatusOK, result)
+	}, apis.RequireAdminAuth())
+
 	// Add the upload history API endpoint
 	e.Router.POST("/_/upload-history", func(c echo.Context) error {
 		// Get the uploaded file
@@ -2,23 +2,214 @@ package botapi
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/Dionid/teleblog/libs/teleblog"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
 	"gopkg.in/telebot.v4"
 )
 
-func AddChannelCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
-	b.Handle("/"+ADD_CHANNEL_COMMAND_NAME, func(c telebot.Context) error {
-		user := &teleblog.User{}
+// findVerifiedUser returns teleblog user bound to the Telegram account
+func findVerifiedUser(app *pocketbase.PocketBase, tgUserId int64) (*teleblog.User, error) {
+	user := &teleblog.User{}
+
+	err := teleblog.UserQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"tg_user_id": tgUserId}).
+		Limit(1).
+		One(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// saveNewChat creates the chat, removed before chat gets its old id,
+// so its kept posts and comments are shown again
+func saveNewChat(app *pocketbase.PocketBase, chat *teleblog.Chat) error {
+	return app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		removedChat := &teleblog.RemovedChat{}
+
+		err := teleblog.RemovedChatQuery(txDao).
+			Where(dbx.HashExp{"user_id": chat.UserId, "tg_chat_id": chat.TgChatId}).
+			Limit(1).
+			One(removedChat)
+		if err != nil && !strings.Contains(err.Error(), "no rows") {
+			return err
+		}
+
+		if err == nil {
+			chat.MarkAsNew()
+			chat.Id = removedChat.ChatId
+
+			if err := txDao.Delete(removedChat); err != nil {
+				return err
+			}
+		}
+
+		return txDao.Save(chat)
+	})
+}
+
+// resolveChannel finds channel by @username or numeric id
+func resolveChannel(b *telebot.Bot, channelRef string) (*telebot.Chat, error) {
+	if channelId, err := strconv.ParseInt(channelRef, 10, 64); err == nil {
+		return b.ChatByID(channelId)
+	}
+
+	if !strings.HasPrefix(channelRef, "@") {
+		channelRef = "@" + channelRef
+	}
+
+	return b.ChatByUsername(channelRef)
+}
+
+// isAdminRole checks if member can manage the chat
+func isAdminRole(role telebot.MemberStatus) bool {
+	return role == telebot.Administrator || role == telebot.Creator
+}
+
+// botRightsWarnings explains what bot can't do because of missing rights
+// in the channel and its discussion group
+func botRightsWarnings(b *telebot.Bot, tgChannel *telebot.Chat) []string {
+	warnings := []string{}
+
+	channelMember, err := b.ChatMemberOf(tgChannel, b.Me)
+	if err != nil || !isAdminRole(channelMember.Role) {
+		warnings = append(warnings, "Bot is not an administrator of the channel, so new posts won't get to the blog. Add the bot to the channel administrators.")
+	}
+
+	if tgChannel.LinkedChatID == 0 {
+		warnings = append(warnings, "Channel has no discussion group, so there will be no comments in the blog.")
+		return warnings
+	}
+
+	groupMember, err := b.ChatMemberOf(&telebot.Chat{ID: tgChannel.LinkedChatID}, b.Me)
+	if err != nil || groupMember.Role == telebot.Left || groupMember.Role == telebot.Kicked {
+		warnings = append(warnings, "Bot is not a member of the discussion group, so comments won't get to the blog. Add the bot to the group as an administrator.")
+	} else if !isAdminRole(groupMember.Role) {
+		warnings = append(warnings, "Bot is not an administrator of the discussion group, so it may miss comments. Add the bot to the group administrators.")
+	}
+
+	return warnings
+}
+
+// addChannel binds the channel and its discussion group to the user
+// and returns feedback for the owner
+func addChannel(b *telebot.Bot, app *pocketbase.PocketBase, user *teleblog.User, tgUser *telebot.User, tgChannel *telebot.Chat) (string, error) {
+	if tgChannel.Type != telebot.ChatChannel && tgChannel.Type != telebot.ChatChannelPrivate {
+		return "This is not a channel.", nil
+	}
+
+	channelMember, err := b.ChatMemberOf(tgChannel, tgUser)
+	if err != nil {
+		return "Can't check that you own this channel. Add the bot to the channel administrators and try again.", nil
+	}
+
+	if !isAdminRole(channelMember.Role) {
+		return "You are not the administrator of the channel.", nil
+	}
+
+	channel := &teleblog.Chat{}
+
+	err = teleblog.ChatQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"tg_chat_id": tgChannel.ID, "user_id": user.Id}).
+		Limit(1).
+		One(channel)
+	if err != nil {
+		if !strings.Contains(err.Error(), "no rows") {
+			return "", err
+		}
+
+		newChannel := teleblog.Chat{
+			UserId:       user.Id,
+			LinkedChatId: "",
+
+			TgUsername:     tgChannel.Username,
+			TgTitle:        tgChannel.Title,
+			TgChatId:       tgChannel.ID,
+			TgType:         string(tgChannel.Type),
+			TgLinkedChatId: tgChannel.LinkedChatID,
+		}
+
+		if err := saveNewChat(app, &newChannel); err != nil {
+			return "", err
+		}
+
+		channel = &newChannel
+	}
+
+	// # Add linked chat
+	if tgChannel.LinkedChatID != 0 {
+		linkedGroup, err := b.ChatByID(tgChannel.LinkedChatID)
+		if err != nil {
+			app.Logger().Error("Error while getting linked group", "error: ", err)
+			return "Channel is added, but bot can't access its discussion group. Add the bot to the group as an administrator and send the command again.", nil
+		}
 
-		err := teleblog.UserQuery(app.Dao()).
-			AndWhere(dbx.HashExp{"tg_user_id": c.Sender().ID}).
+		channelsChat := &teleblog.Chat{}
+
+		err = teleblog.ChatQuery(app.Dao()).
+			AndWhere(dbx.HashExp{"tg_chat_id": linkedGroup.ID, "user_id": user.Id}).
 			Limit(1).
-			One(user)
+			One(channelsChat)
+		if err != nil {
+			if !strings.Contains(err.Error(), "no rows") {
+				return "", err
+			}
+
+			newChannelsChat := teleblog.Chat{
+				UserId:       user.Id,
+				LinkedChatId: channel.Id,
+
+				TgUsername:     linkedGroup.Username,
+				TgTitle:        linkedGroup.Title,
+				TgChatId:       linkedGroup.ID,
+				TgType:         string(linkedGroup.Type),
+				TgLinkedChatId: linkedGroup.LinkedChatID,
+			}
+
+			if err := saveNewChat(app, &newChannelsChat); err != nil {
+				return "", err
+			}
+
+			_, err = app.DB().Update(
+				(&teleblog.Chat{}).TableName(),
+				map[string]interface{}{
+					"linked_chat_id":    newChannelsChat.Id,
+					"tg_linked_chat_id": linkedGroup.ID,
+				},
+				dbx.HashExp{"id": channel.Id},
+			).Execute()
+			if err != nil {
+				app.Logger().Error("Error while updating channel with linked group", "error: ", err)
+				return "Error while updating channel with linked group.", nil
+			}
+		}
+	}
+
+	// # Title, photo and etc.
+	err = SyncChat(b, app, channel)
+	if err != nil {
+		app.Logger().Error("Error while syncing channel", "error: ", err)
+	}
+
+	reply := "Channel and linked group are successfully added."
+
+	if warnings := botRightsWarnings(b, tgChannel); len(warnings) > 0 {
+		reply += "\n\n" + strings.Join(warnings, "\n")
+	}
+
+	return reply, nil
+}
 
+func AddChannelCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	// # By @username or numeric id
+	b.Handle("/"+ADD_CHANNEL_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
 		if err != nil {
 			app.Logger().Error("Error while getting user", "error: ", err)
 			return c.Reply("You are not verified.")
@@ -27,108 +218,128 @@ func AddChannelCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
 		tags := c.Args() // list of arguments splitted by a space
 
 		if len(tags) == 0 {
-			return c.Reply(fmt.Sprintf("You must provide channel name (e.g. /%s @YOUR_CHANNEL_NAME).", ADD_CHANNEL_COMMAND_NAME))
+			return c.Reply(fmt.Sprintf("You must provide channel name or id (e.g. /%s @YOUR_CHANNEL_NAME or /%s -100123456789). You can also forward any post of the channel to me.", ADD_CHANNEL_COMMAND_NAME, ADD_CHANNEL_COMMAND_NAME))
 		}
 
 		if len(tags) > 1 {
 			return c.Reply("You can add only 1 channel at a time.")
 		}
 
-		channelUsername := tags[0]
+		if strings.Contains(tags[0], "t.me/+") || strings.Contains(tags[0], "t.me/joinchat") {
+			return c.Reply("Bots can't open invite links. Forward any post of the channel to me instead.")
+		}
 
-		tgChannel, err := b.ChatByUsername(channelUsername)
+		tgChannel, err := resolveChannel(b, tags[0])
 		if err != nil {
-			return c.Reply("No channel like this found.")
+			return c.Reply("No channel like this found. If the channel is private, add the bot to its administrators first.")
 		}
 
-		if tgChannel.Type != telebot.ChatChannel && tgChannel.Type != telebot.ChatChannelPrivate {
-			return c.Reply("This is not a channel.")
+		reply, err := addChannel(b, app, user, c.Sender(), tgChannel)
+		if err != nil {
+			return err
 		}
 
-		channelMember, err := b.ChatMemberOf(tgChannel, c.Sender())
-		if err != nil {
-			return c.Reply("You cant add not your channels.")
+		return c.Reply(reply)
+	})
+
+	// # By forwarded post. Drafts of scheduled posts are forwarded too,
+	// so only new channels of their administrators are added silently
+	b.Handle(telebot.OnForward, func(c telebot.Context) error {
+		if !c.Message().Private() {
+			return nil
+		}
+
+		origin := c.Message().Origin
+		if origin == nil || origin.Chat == nil {
+			return nil
 		}
 
-		if channelMember.Role != telebot.Administrator && channelMember.Role != telebot.Creator {
-			return c.Reply("You are not the administrator of the channel.")
+		if origin.Chat.Type != telebot.ChatChannel && origin.Chat.Type != telebot.ChatChannelPrivate {
+			return nil
+		}
+
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return nil
 		}
 
-		channel := &teleblog.Chat{}
+		// # Already added channel
+		total := 0
 
 		err = teleblog.ChatQuery(app.Dao()).
-			AndWhere(dbx.HashExp{"tg_chat_id": tgChannel.ID, "user_id": user.Id}).
-			Limit(1).
-			One(channel)
+			Select("count(*)").
+			AndWhere(dbx.HashExp{"tg_chat_id": origin.Chat.ID, "user_id": user.Id}).
+			Row(&total)
 		if err != nil {
-			if !strings.Contains(err.Error(), "no rows") {
-				return err
-			}
+			return err
+		}
 
-			newChannel := teleblog.Chat{
-				UserId:       user.Id,
-				LinkedChatId: "",
+		if total > 0 {
+			return nil
+		}
 
-				TgUsername:     tgChannel.Username,
-				TgChatId:       tgChannel.ID,
-				TgType:         string(tgChannel.Type),
-				TgLinkedChatId: tgChannel.LinkedChatID,
-			}
+		tgChannel, err := b.ChatByID(origin.Chat.ID)
+		if err != nil {
+			return nil
+		}
 
-			if err := app.Dao().Save(&newChannel); err != nil {
-				return err
-			}
+		channelMember, err := b.ChatMemberOf(tgChannel, c.Sender())
+		if err != nil || !isAdminRole(channelMember.Role) {
+			return nil
+		}
 
-			channel = &newChannel
+		reply, err := addChannel(b, app, user, c.Sender(), tgChannel)
+		if err != nil {
+			return err
 		}
 
-		// # Add linked chat
-		if tgChannel.LinkedChatID != 0 {
-			linkedGroup, err := b.ChatByID(tgChannel.LinkedChatID)
-			if err != nil {
-				app.Logger().Error("Error while getting linked group", "error: ", err)
-				return c.Reply(err.Error())
-			}
+		return c.Reply(reply)
+	})
+
+	// # By making bot an administrator of the channel
+	b.Handle(telebot.OnMyChatMember, func(c telebot.Context) error {
+		update := c.ChatMember()
+		if update == nil || update.Sender == nil || update.NewChatMember == nil {
+			return nil
+		}
+
+		if update.Chat.Type != telebot.ChatChannel && update.Chat.Type != telebot.ChatChannelPrivate {
+			return nil
+		}
+
+		if update.NewChatMember.Role != telebot.Administrator {
+			return nil
+		}
 
-			channelsChat := &teleblog.Chat{}
+		if update.OldChatMember != nil && update.OldChatMember.Role == telebot.Administrator {
+			return nil
+		}
 
-			err = teleblog.ChatQuery(app.Dao()).
-				AndWhere(dbx.HashExp{"tg_chat_id": linkedGroup.ID, "user_id": user.Id}).
-				Limit(1).
-				One(channelsChat)
+		notify := func(text string) error {
+			_, err := b.Send(update.Sender, text)
 			if err != nil {
-				if !strings.Contains(err.Error(), "no rows") {
-					return err
-				}
-
-				newChannelsChat := teleblog.Chat{
-					UserId:       user.Id,
-					LinkedChatId: channel.Id,
-
-					TgUsername:     linkedGroup.Username,
-					TgChatId:       linkedGroup.ID,
-					TgType:         string(linkedGroup.Type),
-					TgLinkedChatId: linkedGroup.LinkedChatID,
-				}
-
-				if err := app.Dao().Save(&newChannelsChat); err != nil {
-					return err
-				}
-
-				_, err = app.DB().Update(
-					(&teleblog.Chat{}).TableName(),
-					map[string]interface{}{
-						"tg_linked_chat_id": newChannelsChat.Id,
-					},
-					dbx.HashExp{"id": channel.Id},
-				).Execute()
-				if err != nil {
-					app.Logger().Error("Error while updating channel with linked group", "error: ", err)
-					return c.Reply("Error while updating channel with linked group.")
-				}
+				// # Owner may never have started the bot
+				app.Logger().Error("Error while notifying channel owner", "error: ", err)
 			}
+
+			return nil
+		}
+
+		user, err := findVerifiedUser(app, update.Sender.ID)
+		if err != nil {
+			return notify(fmt.Sprintf("Bot is added to %s, but you are not verified. Verify your account and forward any post of the channel to me.", update.Chat.Title))
+		}
+
+		tgChannel, err := b.ChatByID(update.Chat.ID)
+		if err != nil {
+			return err
+		}
+
+		reply, err := addChannel(b, app, user, update.Sender, tgChannel)
+		if err != nil {
+			return err
 		}
 
-		return c.Reply("Channel and linked group are successfully added.")
+		return notify(reply)
 	})
 }
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Dionid/teleblog/cmd/teleblog/features"
@@ -23,12 +22,51 @@ import (
 
 const ADD_CHANNEL_COMMAND_NAME = "addchannel"
 const VERIFY_TOKEN_COMMAND_NAME = "verifytoken"
+const HIDE_COMMENT_COMMAND_NAME = "hidecomment"
+const SPAM_COMMAND_NAME = "spam"
+const NOT_SPAM_COMMAND_NAME = "notspam"
+const NOTIFICATIONS_COMMAND_NAME = "notifications"
+const LIST_CHANNELS_COMMAND_NAME = "listchannels"
+const REMOVE_CHANNEL_COMMAND_NAME = "removechannel"
+const STATUS_COMMAND_NAME = "status"
+const SYNC_COMMAND_NAME = "sync"
+const SUBSCRIBE_COMMAND_NAME = "subscribe"
+const UNSUBSCRIBE_COMMAND_NAME = "unsubscribe"
+const SCHEDULE_COMMAND_NAME = "schedule"
+const SCHEDULED_COMMAND_NAME = "scheduled"
+const RESCHEDULE_COMMAND_NAME = "reschedule"
+const UNSCHEDULE_COMMAND_NAME = "unschedule"
 
 func skipContent(_ telebot.Context) bool {
 	// # We can't skip content, because we need all posts for links
 	return false
 }
 
+// notifyMediaFailed logs media download error and tells the chat owner about it
+func notifyMediaFailed(app *pocketbase.PocketBase, chat *teleblog.Chat, tgMessageId int, err error) {
+	app.Logger().Error("Error while downloading media", "error", err, "chat_id", chat.Id, "tg_message_id", tgMessageId)
+
+	features.NotifyChatOwner(
+		app,
+		chat.Id,
+		teleblog.NOTIFICATION_MEDIA_FAILED,
+		fmt.Sprintf("Failed to download media of message %d in %s: %s", tgMessageId, chatDisplayName(chat), err),
+	)
+}
+
+// chatDisplayName returns chat title, username or id
+func chatDisplayName(chat *teleblog.Chat) string {
+	if chat.TgTitle != "" {
+		return chat.TgTitle
+	}
+
+	if chat.TgUsername != "" {
+		return "@" + chat.TgUsername
+	}
+
+	return strconv.FormatInt(chat.TgChatId, 10)
+}
+
 // downloadPhoto downloads a photo from a message to the specified directory
 func downloadPhoto(b *telebot.Bot, fileId string, uniqueID string, outputDir string, fileExt string) (string, error) {
 	// Get file info from Telegram
@@ -49,26 +87,47 @@ func downloadPhoto(b *telebot.Bot, fileId string, uniqueID string, outputDir str
 	return filename, nil
 }
 
-func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
+func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase, verificationTokenTTL time.Duration, scheduleLocation *time.Location) error {
 	err := b.SetCommands([]telebot.Command{
-		{Text: "start", Description: "start the bot"},
+		{Text: "start", Description: "start the bot (opened by verification link it binds your account)"},
 		{Text: VERIFY_TOKEN_COMMAND_NAME, Description: "send token to bind bot to your telebot account (e.g. /verifytoken YOUR_TOKEN)"},
-		{Text: ADD_CHANNEL_COMMAND_NAME, Description: "send channel to create blog from it (e.g. /addchannel @YOUR_CHANNEL_NAME)"},
+		{Text: ADD_CHANNEL_COMMAND_NAME, Description: "send channel to create blog from it (e.g. /addchannel @YOUR_CHANNEL_NAME or /addchannel -100123456789)"},
+		{Text: LIST_CHANNELS_COMMAND_NAME, Description: "show your channels with their discussion groups and posts count"},
+		{Text: REMOVE_CHANNEL_COMMAND_NAME, Description: "remove channel from blog (e.g. /removechannel @YOUR_CHANNEL_NAME [purge])"},
+		{Text: STATUS_COMMAND_NAME, Description: "show bot rights, last posts and backlog of your channels"},
+		{Text: SYNC_COMMAND_NAME, Description: "refresh your channels and discussion groups from Telegram"},
+		{Text: SCHEDULE_COMMAND_NAME, Description: "reply to the post sent to the bot to publish it later (e.g. /schedule @YOUR_CHANNEL_NAME 2025-01-31 18:00)"},
+		{Text: SCHEDULED_COMMAND_NAME, Description: "show your scheduled posts"},
+		{Text: RESCHEDULE_COMMAND_NAME, Description: "change publish time of the scheduled post (e.g. /reschedule ID 2025-01-31 18:00)"},
+		{Text: UNSCHEDULE_COMMAND_NAME, Description: "cancel the scheduled post (e.g. /unschedule ID)"},
+		{Text: HIDE_COMMENT_COMMAND_NAME, Description: "reply to the comment in discussion group to hide it from the blog"},
+		{Text: SPAM_COMMAND_NAME, Description: "reply to the comment in discussion group to mark it as spam"},
+		{Text: NOT_SPAM_COMMAND_NAME, Description: "reply to the comment in discussion group to mark it as not spam"},
+		{Text: NOTIFICATIONS_COMMAND_NAME, Description: "show or change notifications (e.g. /notifications new_comments on)"},
+		{Text: SUBSCRIBE_COMMAND_NAME, Description: "get new posts with the tag in private messages (e.g. /subscribe golang)"},
+		{Text: UNSUBSCRIBE_COMMAND_NAME, Description: "stop getting posts with the tag or all of them (e.g. /unsubscribe golang)"},
 	})
 	if err != nil {
 		return err
 	}
 
-	b.Handle("/start", func(c telebot.Context) error {
-		return c.Reply("Hello! This is teleblog bot. Add it to your channel and get posts in your blog.")
-	})
-
 	b.Use(middleware.Recover(func(err error, ctx telebot.Context) {
 		app.Logger().Error("Error in bot: ", "error:", err)
 	}))
 
-	VerifyTokenCommand(b, app)
+	VerifyTokenCommand(b, app, verificationTokenTTL)
 	AddChannelCommand(b, app)
+	ListChannelsCommand(b, app)
+	RemoveChannelCommand(b, app)
+	StatusCommand(b, app)
+	SyncCommand(b, app)
+	ScheduleCommands(b, app, scheduleLocation)
+	HideCommentCommand(b, app)
+	SpamCommentCommands(b, app)
+	ChatSyncHandlers(b, app)
+	NotificationsCommand(b, app)
+	SubscribeCommands(b, app)
+	InlineSearchHandler(b, app)
 
 	b.Handle(telebot.OnChannelPost, func(c telebot.Context) error {
 		chat := &teleblog.Chat{}
@@ -87,6 +146,16 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 
 		rawMessage := c.Message()
 
+		// # Channel title or photo was changed
+		if isChatUpdateMessage(rawMessage) {
+			// # Service message takes message id, but it is not a post
+			if err := features.SkipPostIds(app, chat, rawMessage.ID); err != nil {
+				app.Logger().Error("Error while skipping service message id", "error", err, "chat_id", chat.Id)
+			}
+
+			return syncChatsByTgId(b, app, chat.TgChatId)
+		}
+
 		text := rawMessage.Text + rawMessage.Caption
 
 		newPost := &teleblog.Post{
@@ -97,13 +166,18 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 			AlbumID:        rawMessage.AlbumID,
 			Title:          templu.RemoveNewLines(fmt.Sprintf("%.60s", text)),
 			SeoDescription: templu.RemoveNewLines(fmt.Sprintf("%.160s", text)),
-			Slug:           slug.GenerateSlug(text, time.Now()),
+			Slug:           slug.GenerateSlug(text, rawMessage.Time()),
 		}
 
 		if newPost.AlbumID == "" {
 			newPost.AlbumID = strconv.Itoa(int(rawMessage.Unixtime))
 		}
 
+		// # Reply to other post of the channel
+		if rawMessage.ReplyTo != nil {
+			newPost.TgReplyToPostId = rawMessage.ReplyTo.ID
+		}
+
 		newPost.Created.Scan(rawMessage.Time())
 
 		jsonMessageRaw, err := json.Marshal(rawMessage)
@@ -144,22 +218,23 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 
 			filename, err := downloadPhoto(b, photo.FileID, photo.UniqueID, outputDir, "jpg")
 			if err != nil {
-				return fmt.Errorf("failed to download photo: %w", err)
-			}
+				// # Post is already saved, so don't lose it because of media
+				notifyMediaFailed(app, chat, rawMessage.ID, err)
+			} else {
+				file, err := filesystem.NewFileFromPath(filename)
+				if err != nil {
+					return err
+				}
 
-			file, err := filesystem.NewFileFromPath(filename)
-			if err != nil {
-				return err
-			}
+				fileName := postCollection.Id + "/" + newPost.Id + "/" + file.Name
 
-			fileName := postCollection.Id + "/" + newPost.Id + "/" + file.Name
+				err = fsys.UploadFile(file, fileName)
+				if err != nil {
+					return err
+				}
 
-			err = fsys.UploadFile(file, fileName)
-			if err != nil {
-				return err
+				newPost.Media = append(newPost.Media, file.Name)
 			}
-
-			newPost.Media = append(newPost.Media, file.Name)
 		}
 
 		if video := c.Message().Video; video != nil {
@@ -173,22 +248,23 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 
 			filename, err := downloadPhoto(b, video.FileID, video.UniqueID, outputDir, "mp4")
 			if err != nil {
-				return fmt.Errorf("failed to download photo: %w", err)
-			}
+				// # Post is already saved, so don't lose it because of media
+				notifyMediaFailed(app, chat, rawMessage.ID, err)
+			} else {
+				file, err := filesystem.NewFileFromPath(filename)
+				if err != nil {
+					return err
+				}
 
-			file, err := filesystem.NewFileFromPath(filename)
-			if err != nil {
-				return err
-			}
+				fileName := postCollection.Id + "/" + newPost.Id + "/" + file.Name
 
-			fileName := postCollection.Id + "/" + newPost.Id + "/" + file.Name
+				err = fsys.UploadFile(file, fileName)
+				if err != nil {
+					return err
+				}
 
-			err = fsys.UploadFile(file, fileName)
-			if err != nil {
-				return err
+				newPost.Media = append(newPost.Media, file.Name)
 			}
-
-			newPost.Media = append(newPost.Media, file.Name)
 		}
 
 		err = app.Dao().Save(newPost)
@@ -196,17 +272,31 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 			return err
 		}
 
+		// # Also sends post to tag subscribers
+		if err := features.ExtractAndSavePostTags(app, *newPost); err != nil {
+			app.Logger().Error("Error while saving post tags", "error", err, "post_id", newPost.Id)
+		}
+
+		// # Posts published while bot was down
+		if _, err := features.DetectPostGaps(app, chat); err != nil {
+			app.Logger().Error("Error while detecting missing posts", "error", err, "chat_id", chat.Id)
+		}
+
 		return nil
 	})
 
 	// # Created messages in channels, groups and bot
-	b.Handle(telebot.OnText, func(c telebot.Context) error {
-		app.Logger().Info("telebot.OnText")
-
+	onMessage := func(c telebot.Context) error {
 		if skipContent(c) {
 			return nil
 		}
 
+		// # Drafts of scheduled posts
+		if c.Chat().Type == telebot.ChatPrivate {
+			rememberDraftMessage(c.Message())
+			return nil
+		}
+
 		chat := &teleblog.Chat{}
 		err = teleblog.ChatQuery(app.Dao()).
 			AndWhere(dbx.HashExp{"tg_chat_id": c.Chat().ID}).
@@ -234,54 +324,25 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 				return err
 			}
 
-			newComment := &teleblog.Comment{
-				ChatId:      chat.Id,
-				Text:        c.Message().Text + c.Message().Caption,
-				TgMessageId: c.Message().ID,
-			}
-
-			post := teleblog.Post{}
-
-			// # Bind by thread id
-			if c.Message().ThreadID != 0 {
-				err := teleblog.PostQuery(app.Dao()).
-					AndWhere(dbx.HashExp{"tg_group_message_id": c.Message().ThreadID}).
-					Limit(1).
-					One(&post)
-				if err != nil && !strings.Contains(err.Error(), "no rows in result set") {
-					return err
-				}
-			}
-
-			if post.Id != "" {
-				newComment.PostId = post.Id
-			}
-
-			newComment.Created.Scan(c.Message().Time())
-
-			if c.Message().ReplyTo != nil {
-				newComment.TgReplyToMessageId = c.Message().ReplyTo.ID
-			}
-
-			jsonMessageRaw, err := json.Marshal(c.Message())
-			if err != nil {
-				return err
-			}
-
-			err = newComment.TgMessageRaw.Scan(jsonMessageRaw)
-			if err != nil {
-				return err
-			}
-
-			err = app.Dao().Save(newComment)
-			if err != nil {
-				return err
-			}
+			return saveGroupComment(b, app, chat, c.Message())
 		} else {
 			fmt.Println("Unknown", c.Message().Text)
 		}
 
 		return nil
+	}
+
+	b.Handle(telebot.OnText, func(c telebot.Context) error {
+		app.Logger().Info("telebot.OnText")
+
+		return onMessage(c)
+	})
+
+	// # Photos, videos, stickers, voices, GIFs and etc.
+	b.Handle(telebot.OnMedia, func(c telebot.Context) error {
+		app.Logger().Info("telebot.OnMedia")
+
+		return onMessage(c)
 	})
 
 	// # Edited messages in channels and groups
@@ -375,29 +436,7 @@ func InitBotCommands(b *telebot.Bot, app *pocketbase.PocketBase) error {
 			return nil
 		}
 
-		jsonMessageRaw, err := json.Marshal(rawMessage)
-		if err != nil {
-			return err
-		}
-
-		var tgMessageRaw types.JsonMap
-
-		err = tgMessageRaw.Scan(jsonMessageRaw)
-		if err != nil {
-			return err
-		}
-
-		_, err = app.DB().Update(
-			(&teleblog.Comment{}).TableName(),
-			map[string]interface{}{
-				"text":                  rawMessage.Text,
-				"tg_message_raw":        tgMessageRaw,
-				"is_tg_history_message": false,
-			},
-			dbx.HashExp{"chat_id": chat.Id, "tg_comment_id": rawMessage.ID},
-		).Execute()
-
-		return err
+		return updateGroupComment(b, app, chat, rawMessage)
 	})
 
 	return nil
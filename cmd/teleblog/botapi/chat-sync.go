@@ -0,0 +1,288 @@
+package botapi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"gopkg.in/telebot.v4"
+)
+
+// isChatUpdateMessage checks if message is a service message about
+// chat title or photo change
+func isChatUpdateMessage(m *telebot.Message) bool {
+	return m.NewGroupTitle != "" || m.NewGroupPhoto != nil || m.GroupPhotoDeleted
+}
+
+// syncChatPhoto stores current chat avatar (if it was changed)
+func syncChatPhoto(b *telebot.Bot, app *pocketbase.PocketBase, chat *teleblog.Chat, photo *telebot.ChatPhoto) error {
+	if photo != nil && photo.BigUniqueID == chat.TgPhotoUniqueId && chat.TgPhoto != "" {
+		return nil
+	}
+
+	chatCollection, err := app.Dao().FindCollectionByNameOrId(chat.TableName())
+	if err != nil {
+		return err
+	}
+
+	if chat.TgPhoto != "" {
+		err := deleteRecordMedia(app, chatCollection, chat.Id, []string{chat.TgPhoto})
+		if err != nil {
+			return err
+		}
+
+		chat.TgPhoto = ""
+		chat.TgPhotoUniqueId = ""
+	}
+
+	if photo == nil {
+		return nil
+	}
+
+	outputDir := "temp-tg-chat-photo-" + chat.Id
+	err = os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	filename, err := downloadPhoto(b, photo.BigFileID, photo.BigUniqueID, outputDir, "jpg")
+	if err != nil {
+		return fmt.Errorf("failed to download chat photo: %w", err)
+	}
+
+	file, err := filesystem.NewFileFromPath(filename)
+	if err != nil {
+		return err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	err = fsys.UploadFile(file, chatCollection.Id+"/"+chat.Id+"/"+file.Name)
+	if err != nil {
+		return err
+	}
+
+	chat.TgPhoto = file.Name
+	chat.TgPhotoUniqueId = photo.BigUniqueID
+
+	return nil
+}
+
+// SyncChat refreshes title, username, type, photo and linked chat from Telegram
+func SyncChat(b *telebot.Bot, app *pocketbase.PocketBase, chat *teleblog.Chat) error {
+	tgChat, err := b.ChatByID(chat.TgChatId)
+	if err != nil {
+		return fmt.Errorf("SyncChat: get chat %d error: %w", chat.TgChatId, err)
+	}
+
+	chat.TgTitle = tgChat.Title
+	chat.TgDescription = tgChat.Description
+	chat.TgUsername = tgChat.Username
+	chat.TgType = string(tgChat.Type)
+	chat.TgLinkedChatId = tgChat.LinkedChatID
+
+	// # Linked chat
+	if tgChat.LinkedChatID == 0 {
+		chat.LinkedChatId = ""
+	} else {
+		linkedChat := &teleblog.Chat{}
+
+		err := teleblog.ChatQuery(app.Dao()).
+			AndWhere(dbx.HashExp{"tg_chat_id": tgChat.LinkedChatID, "user_id": chat.UserId}).
+			Limit(1).
+			One(linkedChat)
+		if err != nil {
+			if !strings.Contains(err.Error(), "no rows") {
+				return err
+			}
+
+			// # New discussion group of the channel
+			if tgChat.Type == telebot.ChatChannel || tgChat.Type == telebot.ChatChannelPrivate {
+				linkedGroup, err := b.ChatByID(tgChat.LinkedChatID)
+				if err != nil {
+					return fmt.Errorf("SyncChat: get linked chat %d error: %w", tgChat.LinkedChatID, err)
+				}
+
+				linkedChat = &teleblog.Chat{
+					UserId:       chat.UserId,
+					LinkedChatId: chat.Id,
+
+					TgUsername:     linkedGroup.Username,
+					TgTitle:        linkedGroup.Title,
+					TgChatId:       linkedGroup.ID,
+					TgType:         string(linkedGroup.Type),
+					TgLinkedChatId: linkedGroup.LinkedChatID,
+				}
+
+				if err := saveNewChat(app, linkedChat); err != nil {
+					return err
+				}
+			}
+		}
+
+		chat.LinkedChatId = linkedChat.Id
+	}
+
+	// # Photo
+	err = syncChatPhoto(b, app, chat, tgChat.Photo)
+	if err != nil {
+		app.Logger().Error("Error while syncing chat photo", "error", err, "chat_id", chat.Id)
+	}
+
+	return app.Dao().Save(chat)
+}
+
+// SyncAllChats refreshes metadata of all chats
+func SyncAllChats(b *telebot.Bot, app *pocketbase.PocketBase) error {
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).All(&chats)
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range chats {
+		err := SyncChat(b, app, chat)
+		if err != nil {
+			app.Logger().Error("Error while syncing chat", "error", err, "chat_id", chat.Id)
+			continue
+		}
+
+		app.Logger().Info("Chat synced", "chat_id", chat.Id, "title", chat.TgTitle)
+	}
+
+	return nil
+}
+
+// syncChatsByTgId refreshes all records of the Telegram chat
+func syncChatsByTgId(b *telebot.Bot, app *pocketbase.PocketBase, tgChatId int64) error {
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"tg_chat_id": tgChatId}).
+		All(&chats)
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range chats {
+		if err := SyncChat(b, app, chat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateChat moves chat records from group to its new supergroup
+func migrateChat(app *pocketbase.PocketBase, fromTgChatId int64, toTgChatId int64) error {
+	chatTableName := (&teleblog.Chat{}).TableName()
+
+	_, err := app.DB().Update(
+		chatTableName,
+		dbx.Params{"tg_chat_id": toTgChatId, "tg_type": string(telebot.ChatSuperGroup)},
+		dbx.HashExp{"tg_chat_id": fromTgChatId},
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("migrateChat: update chat error: %w", err)
+	}
+
+	// # Channels linked to the group
+	_, err = app.DB().Update(
+		chatTableName,
+		dbx.Params{"tg_linked_chat_id": toTgChatId},
+		dbx.HashExp{"tg_linked_chat_id": fromTgChatId},
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("migrateChat: update linked chat error: %w", err)
+	}
+
+	return nil
+}
+
+// ChatSyncHandlers keeps chats metadata up to date on Telegram updates
+func ChatSyncHandlers(b *telebot.Bot, app *pocketbase.PocketBase) {
+	syncCurrentChat := func(c telebot.Context) error {
+		return syncChatsByTgId(b, app, c.Chat().ID)
+	}
+
+	b.Handle(telebot.OnNewGroupTitle, syncCurrentChat)
+	b.Handle(telebot.OnNewGroupPhoto, syncCurrentChat)
+	b.Handle(telebot.OnGroupPhotoDeleted, syncCurrentChat)
+
+	b.Handle(telebot.OnMigration, func(c telebot.Context) error {
+		from, to := c.Migration()
+
+		app.Logger().Info("Chat migrated", "from", from, "to", to)
+
+		err := migrateChat(app, from, to)
+		if err != nil {
+			return err
+		}
+
+		return syncChatsByTgId(b, app, to)
+	})
+}
+
+// SyncCommand refreshes owner channels and their discussion groups
+func SyncCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+SYNC_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		channels, err := userChannels(app, user)
+		if err != nil {
+			return err
+		}
+
+		if len(channels) == 0 {
+			return c.Reply(fmt.Sprintf("You have no channels yet, add one with /%s.", ADD_CHANNEL_COMMAND_NAME))
+		}
+
+		lines := []string{}
+
+		for _, channel := range channels {
+			err := SyncChat(b, app, channel)
+			if err != nil {
+				app.Logger().Error("Error while syncing chat", "error", err, "chat_id", channel.Id)
+				lines = append(lines, "", fmt.Sprintf("%s: sync failed, check that bot is an administrator of the channel", chatDisplayName(channel)))
+				continue
+			}
+
+			lines = append(lines, "", fmt.Sprintf("%s: synced", chatDisplayName(channel)))
+
+			linkedChat, err := findLinkedChat(app, channel)
+			if err != nil {
+				return err
+			}
+
+			if linkedChat != nil {
+				err := SyncChat(b, app, linkedChat)
+				if err != nil {
+					app.Logger().Error("Error while syncing chat", "error", err, "chat_id", linkedChat.Id)
+					lines = append(lines, fmt.Sprintf("Discussion group %s: sync failed", chatDisplayName(linkedChat)))
+				} else {
+					lines = append(lines, fmt.Sprintf("Discussion group %s: synced", chatDisplayName(linkedChat)))
+				}
+			}
+
+			tgChannel, err := b.ChatByID(channel.TgChatId)
+			if err == nil {
+				lines = append(lines, botRightsWarnings(b, tgChannel)...)
+			}
+		}
+
+		return c.Reply(strings.TrimSpace(strings.Join(lines, "\n")))
+	})
+}
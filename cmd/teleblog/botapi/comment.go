@@ -0,0 +1,181 @@
+package botapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"gopkg.in/telebot.v4"
+)
+
+// saveGroupComment stores any message (text or media) from linked group as comment
+func saveGroupComment(b *telebot.Bot, app *pocketbase.PocketBase, chat *teleblog.Chat, message *telebot.Message) error {
+	newComment := &teleblog.Comment{
+		ChatId:      chat.Id,
+		Text:        message.Text + message.Caption,
+		TgMessageId: message.ID,
+	}
+
+	newComment.Created.Scan(message.Time())
+
+	if message.ReplyTo != nil {
+		newComment.TgReplyToMessageId = message.ReplyTo.ID
+	}
+
+	jsonMessageRaw, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	err = newComment.TgMessageRaw.Scan(jsonMessageRaw)
+	if err != nil {
+		return err
+	}
+
+	// # Bind by thread id, replied post or comment
+	newComment.PostId, err = features.NewCommentPostResolver(app, chat).Resolve(newComment)
+	if err != nil {
+		return err
+	}
+
+	err = app.Dao().Save(newComment)
+	if err != nil {
+		return err
+	}
+
+	// # Media
+	if tgFile, _ := messageMediaFile(message); tgFile == nil {
+		return nil
+	}
+
+	commentCollection, err := app.Dao().FindCollectionByNameOrId(newComment.TableName())
+	if err != nil {
+		return err
+	}
+
+	fileName, err := uploadMessageMedia(b, app, commentCollection, newComment.Id, message)
+	if err != nil {
+		// # Comment is already saved, so don't lose it because of media
+		notifyMediaFailed(app, chat, message.ID, err)
+		return nil
+	}
+
+	newComment.Media = append(newComment.Media, fileName)
+
+	return app.Dao().Save(newComment)
+}
+
+// updateGroupComment applies edited text, caption and media to stored comment
+func updateGroupComment(b *telebot.Bot, app *pocketbase.PocketBase, chat *teleblog.Chat, message *telebot.Message) error {
+	comment := &teleblog.Comment{}
+
+	err := teleblog.CommentQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"chat_id": chat.Id, "tg_comment_id": message.ID}).
+		Limit(1).
+		One(comment)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return nil
+		}
+		return err
+	}
+
+	// # Check if media was replaced
+	previousMessage := telebot.Message{}
+	previousMediaUniqueId := ""
+
+	jb, err := comment.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if !comment.IsTgHistoryMessage && json.Unmarshal(jb, &previousMessage) == nil {
+		if tgFile, _ := messageMediaFile(&previousMessage); tgFile != nil {
+			previousMediaUniqueId = tgFile.UniqueID
+		}
+	}
+
+	jsonMessageRaw, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	var tgMessageRaw types.JsonMap
+
+	err = tgMessageRaw.Scan(jsonMessageRaw)
+	if err != nil {
+		return err
+	}
+
+	comment.Text = message.Text + message.Caption
+	comment.TgMessageRaw = tgMessageRaw
+	comment.IsTgHistoryMessage = false
+
+	tgFile, _ := messageMediaFile(message)
+
+	if tgFile == nil || tgFile.UniqueID != previousMediaUniqueId || len(comment.Media) == 0 {
+		commentCollection, err := app.Dao().FindCollectionByNameOrId(comment.TableName())
+		if err != nil {
+			return err
+		}
+
+		if len(comment.Media) > 0 {
+			err := deleteRecordMedia(app, commentCollection, comment.Id, comment.Media)
+			if err != nil {
+				app.Logger().Error("Error while deleting comment media", "error", err, "comment_id", comment.Id)
+			}
+
+			comment.Media = types.JsonArray[string]{}
+		}
+
+		if tgFile != nil {
+			fileName, err := uploadMessageMedia(b, app, commentCollection, comment.Id, message)
+			if err != nil {
+				notifyMediaFailed(app, chat, message.ID, err)
+			} else {
+				comment.Media = append(comment.Media, fileName)
+			}
+		}
+	}
+
+	return app.Dao().Save(comment)
+}
+
+// findGroupChat returns teleblog chat by Telegram chat id
+func findGroupChat(app *pocketbase.PocketBase, tgChatId int64) (*teleblog.Chat, error) {
+	chat := &teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"tg_chat_id": tgChatId}).
+		Limit(1).
+		One(chat)
+	if err != nil {
+		return nil, err
+	}
+
+	return chat, nil
+}
+
+// isChatOwner checks if message is sent by the chat owner. Messages on
+// behalf of the linked channel can be sent by any of its admins, so
+// they are not trusted.
+func isChatOwner(app *pocketbase.PocketBase, chat *teleblog.Chat, message *telebot.Message) (bool, error) {
+	owner := &teleblog.User{}
+
+	err := teleblog.UserQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"id": chat.UserId}).
+		Limit(1).
+		One(owner)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return message.Sender != nil && owner.TgUserId == message.Sender.ID, nil
+}
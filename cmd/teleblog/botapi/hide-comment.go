@@ -0,0 +1,54 @@
+package botapi
+
+import (
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+func HideCommentCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+HIDE_COMMENT_COMMAND_NAME, func(c telebot.Context) error {
+		if !c.Message().FromGroup() {
+			return c.Reply("Reply with this command to the comment in the channel discussion group.")
+		}
+
+		if c.Message().ReplyTo == nil {
+			return c.Reply("You must reply to the comment you want to hide.")
+		}
+
+		chat, err := findGroupChat(app, c.Chat().ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.Reply("This group is not added to teleblog.")
+			}
+			return err
+		}
+
+		isOwner, err := isChatOwner(app, chat, c.Message())
+		if err != nil {
+			return err
+		}
+
+		if !isOwner {
+			return c.Reply("Only the blog owner can hide comments.")
+		}
+
+		result, err := app.DB().Update(
+			(&teleblog.Comment{}).TableName(),
+			dbx.Params{"hidden": true},
+			dbx.HashExp{"chat_id": chat.Id, "tg_comment_id": c.Message().ReplyTo.ID},
+		).Execute()
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return c.Reply("Comment not found.")
+		}
+
+		return c.Reply("Comment is hidden.")
+	})
+}
@@ -0,0 +1,205 @@
+package botapi
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+const inlineResultsPerPage = 20
+
+// parseInlineQuery splits query into full text search and #tag
+func parseInlineQuery(text string) teleblog.PostsFilters {
+	filters := teleblog.PostsFilters{}
+	words := []string{}
+
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, "#") && len(word) > 1 && filters.Tag == "" {
+			// # Tags are stored without #
+			filters.Tag = strings.TrimPrefix(word, "#")
+			continue
+		}
+
+		words = append(words, word)
+	}
+
+	filters.Search = strings.Join(words, " ")
+
+	return filters
+}
+
+// isImageMedia checks if media file can be used as thumbnail
+func isImageMedia(media string) bool {
+	switch strings.ToLower(path.Ext(media)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	default:
+		return false
+	}
+}
+
+// inlineSearchSite is the site where posts of the chat are linked
+type inlineSearchSite struct {
+	url        string
+	permalinks *teleblog.Permalinks
+}
+
+func newInlineSearchSite(app *pocketbase.PocketBase, chat *teleblog.Chat) (*inlineSearchSite, error) {
+	siteConfig, err := teleblog.FindChatTenantConfig(app.Dao(), chat)
+	if err != nil {
+		return nil, err
+	}
+
+	permalinks, err := teleblog.NewPermalinks(app.Dao(), siteConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inlineSearchSite{
+		url:        siteConfig.SiteUrl(app.Settings().Meta.AppUrl),
+		permalinks: permalinks,
+	}, nil
+}
+
+// InlineSearchHandler lets users search blog posts with @bot query
+// from any chat and share them
+func InlineSearchHandler(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle(telebot.OnQuery, func(c telebot.Context) error {
+		query := c.Query()
+
+		// # Posts of the default site are searched
+		siteConfig, err := teleblog.FindTenantConfig(app.Dao(), "")
+		if err != nil {
+			return err
+		}
+
+		chats := []teleblog.Chat{}
+
+		err = teleblog.TenantChatQuery(app.Dao(), siteConfig).
+			AndWhere(dbx.HashExp{"tg_type": []any{"channel", "privatechannel"}}).
+			All(&chats)
+		if err != nil {
+			return err
+		}
+
+		chatIds := []interface{}{}
+		chatsById := map[string]*teleblog.Chat{}
+
+		for i, chat := range chats {
+			chatIds = append(chatIds, chat.Id)
+			chatsById[chat.Id] = &chats[i]
+		}
+
+		// # Posts are linked on sites of their chats, chat id -> site
+		chatSites := map[string]*inlineSearchSite{}
+
+		// # Pagination
+		offset, _ := strconv.Atoi(query.Offset)
+
+		albums := []struct {
+			AlbumID string `db:"album_id"`
+		}{}
+
+		err = teleblog.PostsListQuery(app.Dao(), parseInlineQuery(query.Text), chatIds...).
+			Select("post.album_id").
+			GroupBy("post.album_id").
+			OrderBy("MAX(post.created) desc").
+			Limit(inlineResultsPerPage).
+			Offset(int64(offset)).
+			All(&albums)
+		if err != nil {
+			return err
+		}
+
+		postCollection, err := app.Dao().FindCollectionByNameOrId("post")
+		if err != nil {
+			return err
+		}
+
+		results := telebot.Results{}
+
+		for _, album := range albums {
+			posts := []teleblog.Post{}
+
+			err := teleblog.PostQuery(app.Dao()).
+				Where(dbx.HashExp{"album_id": album.AlbumID}).
+				AndWhere(dbx.In("chat_id", chatIds...)).
+				OrderBy("tg_post_id asc").
+				All(&posts)
+			if err != nil {
+				return err
+			}
+
+			if len(posts) == 0 {
+				continue
+			}
+
+			site, ok := chatSites[posts[0].ChatId]
+			if !ok {
+				site, err = newInlineSearchSite(app, chatsById[posts[0].ChatId])
+				if err != nil {
+					return err
+				}
+
+				chatSites[posts[0].ChatId] = site
+			}
+
+			// # Album text is stored in one of its posts
+			post := posts[0]
+			thumbUrl := ""
+
+			for _, albumPost := range posts {
+				if albumPost.Text != "" && post.Text == "" {
+					post = albumPost
+				}
+
+				for _, media := range albumPost.Media {
+					if thumbUrl == "" && isImageMedia(media) {
+						thumbUrl = site.url + "/api/files/" + postCollection.Id + "/" + albumPost.Id + "/" + media
+					}
+				}
+			}
+
+			title := post.Title
+			if title == "" {
+				title = templu.RemoveNewLines(fmt.Sprintf("%.60s", post.Text))
+			}
+			if title == "" {
+				title = post.Created.Time().Format("2006-01-02 15:04")
+			}
+
+			postUrl := site.url + site.permalinks.PostPath(post)
+
+			result := &telebot.ArticleResult{
+				Title:       title,
+				Description: templu.RemoveNewLines(fmt.Sprintf("%.150s", post.Text)),
+				URL:         postUrl,
+				ThumbURL:    thumbUrl,
+			}
+			result.SetResultID(post.Id)
+			result.SetContent(&telebot.InputTextMessageContent{
+				Text: title + "\n\n" + postUrl,
+			})
+
+			results = append(results, result)
+		}
+
+		nextOffset := ""
+		if len(albums) == inlineResultsPerPage {
+			nextOffset = strconv.Itoa(offset + inlineResultsPerPage)
+		}
+
+		return c.Answer(&telebot.QueryResponse{
+			Results:    results,
+			NextOffset: nextOffset,
+			CacheTime:  60,
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package botapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// userChannels returns channels added by the user
+func userChannels(app *pocketbase.PocketBase, user *teleblog.User) ([]*teleblog.Chat, error) {
+	channels := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		AndWhere(dbx.HashExp{
+			"user_id": user.Id,
+			"tg_type": []any{string(telebot.ChatChannel), string(telebot.ChatChannelPrivate)},
+		}).
+		OrderBy("created asc").
+		All(&channels)
+	if err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// findUserChannel finds channel of the user by @username or numeric id
+func findUserChannel(app *pocketbase.PocketBase, user *teleblog.User, channelRef string) (*teleblog.Chat, error) {
+	channels, err := userChannels(app, user)
+	if err != nil {
+		return nil, err
+	}
+
+	channelId, _ := strconv.ParseInt(channelRef, 10, 64)
+	channelUsername := strings.TrimPrefix(channelRef, "@")
+
+	for _, channel := range channels {
+		if (channelId != 0 && channel.TgChatId == channelId) ||
+			(channel.TgUsername != "" && strings.EqualFold(channel.TgUsername, channelUsername)) {
+			return channel, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// findLinkedChat returns discussion group of the channel (nil if there is no one)
+func findLinkedChat(app *pocketbase.PocketBase, channel *teleblog.Chat) (*teleblog.Chat, error) {
+	if channel.LinkedChatId == "" {
+		return nil, nil
+	}
+
+	linkedChat := &teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"id": channel.LinkedChatId}).
+		Limit(1).
+		One(linkedChat)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return linkedChat, nil
+}
+
+func ListChannelsCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+LIST_CHANNELS_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		channels, err := userChannels(app, user)
+		if err != nil {
+			return err
+		}
+
+		if len(channels) == 0 {
+			return c.Reply(fmt.Sprintf("You have no channels yet, add one with /%s.", ADD_CHANNEL_COMMAND_NAME))
+		}
+
+		lines := []string{"Your channels:"}
+
+		for _, channel := range channels {
+			var postsCount int
+
+			err := teleblog.PostQuery(app.Dao()).
+				Select("count(*)").
+				AndWhere(dbx.HashExp{"chat_id": channel.Id}).
+				Row(&postsCount)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, "", fmt.Sprintf("%s (%d)", chatDisplayName(channel), channel.TgChatId))
+			lines = append(lines, fmt.Sprintf("Posts: %d", postsCount))
+
+			linkedChat, err := findLinkedChat(app, channel)
+			if err != nil {
+				return err
+			}
+
+			if linkedChat == nil {
+				lines = append(lines, "Discussion group: none")
+				continue
+			}
+
+			var commentsCount int
+
+			err = teleblog.CommentQuery(app.Dao()).
+				Select("count(*)").
+				AndWhere(dbx.HashExp{"chat_id": linkedChat.Id}).
+				Row(&commentsCount)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, fmt.Sprintf("Discussion group: %s (%d), comments: %d", chatDisplayName(linkedChat), linkedChat.TgChatId, commentsCount))
+		}
+
+		return c.Reply(strings.Join(lines, "\n"))
+	})
+}
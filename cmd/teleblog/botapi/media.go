@@ -0,0 +1,133 @@
+package botapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"gopkg.in/telebot.v4"
+)
+
+func extOrDefault(fileName string, defaultExt string) string {
+	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	if ext == "" {
+		return defaultExt
+	}
+
+	return strings.ToLower(ext)
+}
+
+// messageMediaFile returns the file attached to the message and
+// the extension it must be saved with (nil if there is no media)
+func messageMediaFile(m *telebot.Message) (*telebot.File, string) {
+	switch {
+	case m.Photo != nil:
+		return &m.Photo.File, "jpg"
+	case m.Video != nil:
+		return &m.Video.File, "mp4"
+	case m.Animation != nil:
+		return &m.Animation.File, "mp4"
+	case m.VideoNote != nil:
+		return &m.VideoNote.File, "mp4"
+	case m.Voice != nil:
+		return &m.Voice.File, "ogg"
+	case m.Audio != nil:
+		return &m.Audio.File, extOrDefault(m.Audio.FileName, "mp3")
+	case m.Sticker != nil:
+		if m.Sticker.Video {
+			return &m.Sticker.File, "webm"
+		}
+
+		// # Animated stickers are .tgs, which browsers can't show, so take thumbnail
+		if m.Sticker.Animated {
+			if m.Sticker.Thumbnail == nil {
+				return nil, ""
+			}
+
+			return &m.Sticker.Thumbnail.File, "webp"
+		}
+
+		return &m.Sticker.File, "webp"
+	case m.Document != nil:
+		return &m.Document.File, extOrDefault(m.Document.FileName, "bin")
+	}
+
+	return nil, ""
+}
+
+// uploadMessageMedia downloads media of the message and uploads it
+// to the record storage, returning stored file name
+func uploadMessageMedia(
+	b *telebot.Bot,
+	app core.App,
+	collection *models.Collection,
+	recordId string,
+	m *telebot.Message,
+) (string, error) {
+	tgFile, ext := messageMediaFile(m)
+	if tgFile == nil {
+		return "", nil
+	}
+
+	outputDir := "temp-tg-webhook-uploads-" + recordId
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	filename, err := downloadPhoto(b, tgFile.FileID, tgFile.UniqueID, outputDir, ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to download media: %w", err)
+	}
+
+	file, err := filesystem.NewFileFromPath(filename)
+	if err != nil {
+		return "", err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return "", err
+	}
+	defer fsys.Close()
+
+	err = fsys.UploadFile(file, collection.Id+"/"+recordId+"/"+file.Name)
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name, nil
+}
+
+// deleteRecordMedia removes previously uploaded files of the record
+func deleteRecordMedia(app core.App, collection *models.Collection, recordId string, media []string) error {
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	for _, name := range media {
+		fileKey := collection.Id + "/" + recordId + "/" + name
+
+		exists, err := fsys.Exists(fileKey)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			continue
+		}
+
+		if err := fsys.Delete(fileKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
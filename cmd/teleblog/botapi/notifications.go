@@ -0,0 +1,75 @@
+package botapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// NotifyUpdateError tells owners about channel posts bot failed to save
+func NotifyUpdateError(app *pocketbase.PocketBase, err error, c telebot.Context) {
+	if c == nil {
+		return
+	}
+
+	message := c.Update().ChannelPost
+	if message == nil {
+		message = c.Update().EditedChannelPost
+	}
+
+	if message == nil || message.Chat == nil {
+		return
+	}
+
+	features.NotifyTgChatOwners(
+		app,
+		message.Chat.ID,
+		teleblog.NOTIFICATION_POST_FAILED,
+		fmt.Sprintf("Failed to save post %d from %s: %s", message.ID, message.Chat.Title, err),
+	)
+}
+
+// NotificationsCommand shows and changes notification settings of the owner
+func NotificationsCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+NOTIFICATIONS_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		args := c.Args()
+
+		if len(args) == 0 {
+			lines := []string{"Notifications:"}
+
+			for _, kind := range teleblog.NotificationKinds {
+				state := "off"
+				if user.NotificationEnabled(kind) {
+					state = "on"
+				}
+
+				lines = append(lines, fmt.Sprintf("%s – %s", kind, state))
+			}
+
+			lines = append(lines, "", fmt.Sprintf("Change with /%s KIND on|off", NOTIFICATIONS_COMMAND_NAME))
+
+			return c.Reply(strings.Join(lines, "\n"))
+		}
+
+		if len(args) != 2 || !teleblog.IsNotificationKind(args[0]) || (args[1] != "on" && args[1] != "off") {
+			return c.Reply(fmt.Sprintf("Usage: /%s KIND on|off, where KIND is one of: %s", NOTIFICATIONS_COMMAND_NAME, strings.Join(teleblog.NotificationKinds, ", ")))
+		}
+
+		user.SetNotificationEnabled(args[0], args[1] == "on")
+
+		if err := app.Dao().Save(user); err != nil {
+			return err
+		}
+
+		return c.Reply(fmt.Sprintf("Notifications %s are turned %s.", args[0], args[1]))
+	})
+}
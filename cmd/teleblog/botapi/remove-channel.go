@@ -0,0 +1,46 @@
+package botapi
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+func RemoveChannelCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+REMOVE_CHANNEL_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		args := c.Args()
+
+		if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[1] != "purge") {
+			return c.Reply(fmt.Sprintf("You must provide channel name or id (e.g. /%s @YOUR_CHANNEL_NAME). Add purge to delete its posts and comments too (e.g. /%s @YOUR_CHANNEL_NAME purge).", REMOVE_CHANNEL_COMMAND_NAME, REMOVE_CHANNEL_COMMAND_NAME))
+		}
+
+		purge := len(args) == 2
+
+		channel, err := findUserChannel(app, user, args[0])
+		if err != nil {
+			return err
+		}
+
+		if channel == nil {
+			return c.Reply(fmt.Sprintf("Channel not found, see your channels with /%s.", LIST_CHANNELS_COMMAND_NAME))
+		}
+
+		err = features.RemoveChat(app, channel, purge)
+		if err != nil {
+			return err
+		}
+
+		if purge {
+			return c.Reply(fmt.Sprintf("Channel %s, its discussion group, posts and comments are removed.", chatDisplayName(channel)))
+		}
+
+		return c.Reply(fmt.Sprintf("Channel %s and its discussion group are removed. Posts and comments are kept in the database, but are not shown in the blog until you add the channel again.", chatDisplayName(channel)))
+	})
+}
@@ -0,0 +1,306 @@
+package botapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"gopkg.in/telebot.v4"
+)
+
+const scheduleTimeLayout = "2006-01-02 15:04"
+
+// # Albums sent to the bot come as separate messages, so remember them
+// # to schedule the whole album by reply to any of its messages
+var draftAlbums = struct {
+	sync.Mutex
+	// # tg chat id + album id -> message ids
+	messages map[string][]int
+}{
+	messages: map[string][]int{},
+}
+
+// rememberDraftMessage remembers album message sent to the bot in private chat
+func rememberDraftMessage(m *telebot.Message) {
+	if m.AlbumID == "" {
+		return
+	}
+
+	draftAlbums.Lock()
+	defer draftAlbums.Unlock()
+
+	// # Drafts are scheduled right after sending, so old ones are not needed
+	if len(draftAlbums.messages) > 1000 {
+		draftAlbums.messages = map[string][]int{}
+	}
+
+	key := fmt.Sprintf("%d:%s", m.Chat.ID, m.AlbumID)
+	draftAlbums.messages[key] = append(draftAlbums.messages[key], m.ID)
+}
+
+// draftMessageIds returns ids of all messages of the draft
+func draftMessageIds(m *telebot.Message) []int {
+	if m.AlbumID == "" {
+		return []int{m.ID}
+	}
+
+	draftAlbums.Lock()
+	defer draftAlbums.Unlock()
+
+	ids := append([]int{}, draftAlbums.messages[fmt.Sprintf("%d:%s", m.Chat.ID, m.AlbumID)]...)
+	if len(ids) == 0 {
+		return []int{m.ID}
+	}
+
+	// # Telegram copies messages only in increasing order
+	sort.Ints(ids)
+
+	return ids
+}
+
+func parsePublishAt(date string, clock string, location *time.Location) (types.DateTime, error) {
+	publishAt, err := time.ParseInLocation(scheduleTimeLayout, date+" "+clock, location)
+	if err != nil {
+		return types.DateTime{}, fmt.Errorf("Publish time must be in format %s", scheduleTimeLayout)
+	}
+
+	if publishAt.Before(time.Now()) {
+		return types.DateTime{}, fmt.Errorf("Publish time must be in the future")
+	}
+
+	return types.ParseDateTime(publishAt)
+}
+
+// findUserScheduledPost finds not published post of the user
+func findUserScheduledPost(app *pocketbase.PocketBase, user *teleblog.User, id string) (*teleblog.ScheduledPost, error) {
+	scheduledPost := &teleblog.ScheduledPost{}
+
+	err := teleblog.ScheduledPostQuery(app.Dao()).
+		Where(dbx.HashExp{
+			"id":      id,
+			"user_id": user.Id,
+			"status":  []any{teleblog.SCHEDULED_POST_STATUS_SCHEDULED, teleblog.SCHEDULED_POST_STATUS_FAILED},
+		}).
+		Limit(1).
+		One(scheduledPost)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return scheduledPost, nil
+}
+
+// ScheduleCommands lets owners send drafts to the bot and publish
+// them to their channels later
+func ScheduleCommands(b *telebot.Bot, app *pocketbase.PocketBase, location *time.Location) {
+	b.Handle("/"+SCHEDULE_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		args := c.Args()
+		draft := c.Message().ReplyTo
+
+		if draft == nil || len(args) != 3 {
+			return c.Reply(fmt.Sprintf("Send post (text, media or album) to the bot and reply to it with /%s @YOUR_CHANNEL_NAME %s (time zone is %s).", SCHEDULE_COMMAND_NAME, scheduleTimeLayout, location))
+		}
+
+		channel, err := findUserChannel(app, user, args[0])
+		if err != nil {
+			return err
+		}
+
+		if channel == nil {
+			return c.Reply(fmt.Sprintf("Channel not found, see your channels with /%s.", LIST_CHANNELS_COMMAND_NAME))
+		}
+
+		publishAt, err := parsePublishAt(args[1], args[2], location)
+		if err != nil {
+			return c.Reply(err.Error() + ".")
+		}
+
+		scheduledPost := &teleblog.ScheduledPost{
+			UserId:             user.Id,
+			ChatId:             channel.Id,
+			Text:               draft.Text + draft.Caption,
+			PublishAt:          publishAt,
+			Status:             teleblog.SCHEDULED_POST_STATUS_SCHEDULED,
+			TgSourceChatId:     c.Chat().ID,
+			TgSourceMessageIds: draftMessageIds(draft),
+		}
+
+		err = app.Dao().Save(scheduledPost)
+		if err != nil {
+			return err
+		}
+
+		return c.Reply(fmt.Sprintf(
+			"Post %s will be published to %s at %s. Don't delete it from this chat until then.",
+			scheduledPost.Id,
+			chatDisplayName(channel),
+			publishAt.Time().In(location).Format(scheduleTimeLayout),
+		))
+	})
+
+	b.Handle("/"+SCHEDULED_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		scheduledPosts := []*teleblog.ScheduledPost{}
+
+		err = teleblog.ScheduledPostQuery(app.Dao()).
+			Where(dbx.HashExp{
+				"user_id": user.Id,
+				"status": []any{
+					teleblog.SCHEDULED_POST_STATUS_SCHEDULED,
+					teleblog.SCHEDULED_POST_STATUS_PUBLISHING,
+					teleblog.SCHEDULED_POST_STATUS_FAILED,
+				},
+			}).
+			OrderBy("publish_at asc").
+			All(&scheduledPosts)
+		if err != nil {
+			return err
+		}
+
+		if len(scheduledPosts) == 0 {
+			return c.Reply(fmt.Sprintf("You have no scheduled posts. Schedule one with /%s.", SCHEDULE_COMMAND_NAME))
+		}
+
+		channels, err := userChannels(app, user)
+		if err != nil {
+			return err
+		}
+
+		channelNames := map[string]string{}
+		for _, channel := range channels {
+			channelNames[channel.Id] = chatDisplayName(channel)
+		}
+
+		lines := []string{"Scheduled posts:"}
+
+		for _, scheduledPost := range scheduledPosts {
+			line := fmt.Sprintf(
+				"%s – %s – %s – %s",
+				scheduledPost.Id,
+				channelNames[scheduledPost.ChatId],
+				scheduledPost.PublishAt.Time().In(location).Format(scheduleTimeLayout),
+				templu.RemoveNewLines(fmt.Sprintf("%.40s", scheduledPost.Text)),
+			)
+
+			if scheduledPost.Status == teleblog.SCHEDULED_POST_STATUS_FAILED {
+				line += fmt.Sprintf(" (failed: %s)", scheduledPost.Error)
+			}
+
+			if scheduledPost.Status == teleblog.SCHEDULED_POST_STATUS_PUBLISHING {
+				line += " (publishing)"
+			}
+
+			lines = append(lines, line)
+		}
+
+		lines = append(lines, "", fmt.Sprintf("Change time with /%s ID %s or cancel with /%s ID", RESCHEDULE_COMMAND_NAME, scheduleTimeLayout, UNSCHEDULE_COMMAND_NAME))
+
+		return c.Reply(strings.Join(lines, "\n"))
+	})
+
+	b.Handle("/"+RESCHEDULE_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		args := c.Args()
+
+		if len(args) != 3 {
+			return c.Reply(fmt.Sprintf("Usage: /%s ID %s, see ids with /%s", RESCHEDULE_COMMAND_NAME, scheduleTimeLayout, SCHEDULED_COMMAND_NAME))
+		}
+
+		scheduledPost, err := findUserScheduledPost(app, user, args[0])
+		if err != nil {
+			return err
+		}
+
+		if scheduledPost == nil {
+			return c.Reply(fmt.Sprintf("Scheduled post not found, see your posts with /%s.", SCHEDULED_COMMAND_NAME))
+		}
+
+		publishAt, err := parsePublishAt(args[1], args[2], location)
+		if err != nil {
+			return c.Reply(err.Error() + ".")
+		}
+
+		// # Failed posts are retried at new time
+		scheduledPost.PublishAt = publishAt
+		scheduledPost.Status = teleblog.SCHEDULED_POST_STATUS_SCHEDULED
+		scheduledPost.Error = ""
+
+		err = app.Dao().Save(scheduledPost)
+		if err != nil {
+			return err
+		}
+
+		return c.Reply(fmt.Sprintf("Post %s will be published at %s.", scheduledPost.Id, publishAt.Time().In(location).Format(scheduleTimeLayout)))
+	})
+
+	b.Handle("/"+UNSCHEDULE_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		args := c.Args()
+
+		if len(args) != 1 {
+			return c.Reply(fmt.Sprintf("Usage: /%s ID, see ids with /%s", UNSCHEDULE_COMMAND_NAME, SCHEDULED_COMMAND_NAME))
+		}
+
+		scheduledPost, err := findUserScheduledPost(app, user, args[0])
+		if err != nil {
+			return err
+		}
+
+		if scheduledPost == nil {
+			return c.Reply(fmt.Sprintf("Scheduled post not found, see your posts with /%s.", SCHEDULED_COMMAND_NAME))
+		}
+
+		scheduledPost.Status = teleblog.SCHEDULED_POST_STATUS_CANCELED
+
+		err = app.Dao().Save(scheduledPost)
+		if err != nil {
+			return err
+		}
+
+		return c.Reply(fmt.Sprintf("Post %s is canceled.", scheduledPost.Id))
+	})
+}
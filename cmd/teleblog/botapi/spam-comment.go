@@ -0,0 +1,75 @@
+package botapi
+
+import (
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// SpamCommentCommands let owner label comments to train spam classifier
+func SpamCommentCommands(b *telebot.Bot, app *pocketbase.PocketBase) {
+	labelComment := func(c telebot.Context, label string) error {
+		if !c.Message().FromGroup() {
+			return c.Reply("Reply with this command to the comment in the channel discussion group.")
+		}
+
+		if c.Message().ReplyTo == nil {
+			return c.Reply("You must reply to the comment you want to label.")
+		}
+
+		chat, err := findGroupChat(app, c.Chat().ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.Reply("This group is not added to teleblog.")
+			}
+			return err
+		}
+
+		isOwner, err := isChatOwner(app, chat, c.Message())
+		if err != nil {
+			return err
+		}
+
+		if !isOwner {
+			return c.Reply("Only the blog owner can label comments.")
+		}
+
+		comment := &teleblog.Comment{}
+
+		err = teleblog.CommentQuery(app.Dao()).
+			AndWhere(dbx.HashExp{"chat_id": chat.Id, "tg_comment_id": c.Message().ReplyTo.ID}).
+			Limit(1).
+			One(comment)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.Reply("Comment not found.")
+			}
+			return err
+		}
+
+		comment.SpamLabel = label
+
+		// # Saving through dao retrains classifier and updates spam flag
+		err = app.Dao().Save(comment)
+		if err != nil {
+			return err
+		}
+
+		if label == teleblog.SPAM_LABEL {
+			return c.Reply("Comment is marked as spam and hidden.")
+		}
+
+		return c.Reply("Comment is marked as not spam.")
+	}
+
+	b.Handle("/"+SPAM_COMMAND_NAME, func(c telebot.Context) error {
+		return labelComment(c, teleblog.SPAM_LABEL)
+	})
+
+	b.Handle("/"+NOT_SPAM_COMMAND_NAME, func(c telebot.Context) error {
+		return labelComment(c, teleblog.HAM_LABEL)
+	})
+}
@@ -0,0 +1,115 @@
+package botapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// botRole describes bot membership in the chat
+func botRole(b *telebot.Bot, tgChatId int64) string {
+	member, err := b.ChatMemberOf(&telebot.Chat{ID: tgChatId}, b.Me)
+	if err != nil {
+		return "no access"
+	}
+
+	switch member.Role {
+	case telebot.Creator, telebot.Administrator:
+		return "administrator"
+	case telebot.Member, telebot.Restricted:
+		return "member, not administrator"
+	default:
+		return "not a member"
+	}
+}
+
+func StatusCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+STATUS_COMMAND_NAME, func(c telebot.Context) error {
+		user, err := findVerifiedUser(app, c.Sender().ID)
+		if err != nil {
+			return c.Reply("You are not verified.")
+		}
+
+		channels, err := userChannels(app, user)
+		if err != nil {
+			return err
+		}
+
+		if len(channels) == 0 {
+			return c.Reply(fmt.Sprintf("You have no channels yet, add one with /%s.", ADD_CHANNEL_COMMAND_NAME))
+		}
+
+		lines := []string{fmt.Sprintf("Bot @%s is running.", b.Me.Username)}
+
+		for _, channel := range channels {
+			lines = append(lines, "", chatDisplayName(channel))
+			lines = append(lines, "Bot in channel: "+botRole(b, channel.TgChatId))
+
+			// # Last ingested post
+			lastPost := &teleblog.Post{}
+
+			err := teleblog.PostQuery(app.Dao()).
+				AndWhere(dbx.HashExp{"chat_id": channel.Id}).
+				OrderBy("created desc").
+				Limit(1).
+				One(lastPost)
+			if err != nil {
+				if !strings.Contains(err.Error(), "no rows") {
+					return err
+				}
+
+				lines = append(lines, "Last post: none")
+			} else {
+				lines = append(lines, fmt.Sprintf("Last post: %d at %s", lastPost.TgMessageId, lastPost.Created.Time().Format("2006-01-02 15:04")))
+			}
+
+			// # Backlog
+			var unparsableCount int
+
+			err = teleblog.PostQuery(app.Dao()).
+				Select("count(*)").
+				AndWhere(dbx.HashExp{"chat_id": channel.Id, "unparsable": true}).
+				Row(&unparsableCount)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, fmt.Sprintf("Unparsable posts: %d", unparsableCount))
+
+			if len(channel.TgPostGaps) > 0 {
+				lines = append(lines, "Missing posts: "+teleblog.FormatPostGaps(channel.TgPostGaps))
+			}
+
+			linkedChat, err := findLinkedChat(app, channel)
+			if err != nil {
+				return err
+			}
+
+			if linkedChat == nil {
+				lines = append(lines, "Discussion group: none")
+				continue
+			}
+
+			lines = append(lines, "Bot in discussion group: "+botRole(b, linkedChat.TgChatId))
+
+			var orphanCommentsCount int
+
+			err = teleblog.CommentQuery(app.Dao()).
+				Select("count(*)").
+				AndWhere(dbx.HashExp{"chat_id": linkedChat.Id}).
+				AndWhere(dbx.NewExp("post_id IS NULL OR post_id = ''")).
+				Row(&orphanCommentsCount)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, fmt.Sprintf("Comments without post: %d", orphanCommentsCount))
+		}
+
+		return c.Reply(strings.Join(lines, "\n"))
+	})
+}
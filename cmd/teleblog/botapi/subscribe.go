@@ -0,0 +1,147 @@
+package botapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// userSubscribedTags returns tags user is subscribed to
+func userSubscribedTags(app *pocketbase.PocketBase, tgUserId int64) ([]teleblog.Tag, error) {
+	tags := []teleblog.Tag{}
+
+	err := teleblog.TagQuery(app.Dao()).
+		InnerJoin("tag_subscription", dbx.NewExp("tag_subscription.tag_id = tag.id")).
+		Where(dbx.HashExp{"tag_subscription.tg_user_id": tgUserId}).
+		OrderBy("tag.value asc").
+		All(&tags)
+
+	return tags, err
+}
+
+func formatTags(tags []teleblog.Tag) string {
+	values := []string{}
+	for _, tag := range tags {
+		values = append(values, "#"+tag.Value)
+	}
+
+	return strings.Join(values, " ")
+}
+
+// SubscribeCommands lets readers get new posts with chosen tags
+// in private messages
+func SubscribeCommands(b *telebot.Bot, app *pocketbase.PocketBase) {
+	b.Handle("/"+SUBSCRIBE_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		args := c.Args()
+
+		if len(args) == 0 {
+			tags, err := userSubscribedTags(app, c.Sender().ID)
+			if err != nil {
+				return err
+			}
+
+			if len(tags) == 0 {
+				return c.Reply(fmt.Sprintf("You have no subscriptions. Subscribe with /%s TAG", SUBSCRIBE_COMMAND_NAME))
+			}
+
+			return c.Reply(fmt.Sprintf("Your subscriptions: %s", formatTags(tags)))
+		}
+
+		subscribed := []teleblog.Tag{}
+		notFound := []string{}
+
+		for _, arg := range args {
+			// # Tags are stored without #
+			value := strings.TrimPrefix(arg, "#")
+
+			tag := teleblog.Tag{}
+
+			err := teleblog.TagQuery(app.Dao()).
+				Where(dbx.HashExp{"value": value}).
+				Limit(1).
+				One(&tag)
+			if err != nil {
+				if !strings.Contains(err.Error(), "no rows") {
+					return err
+				}
+
+				notFound = append(notFound, arg)
+				continue
+			}
+
+			subscription := &teleblog.TagSubscription{
+				TgUserId:   c.Sender().ID,
+				TgUsername: c.Sender().Username,
+				TagId:      tag.Id,
+			}
+
+			err = app.Dao().Save(subscription)
+			if err != nil && !strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return err
+			}
+
+			subscribed = append(subscribed, tag)
+		}
+
+		lines := []string{}
+
+		if len(subscribed) > 0 {
+			lines = append(lines, fmt.Sprintf("You will get new posts with %s.", formatTags(subscribed)))
+		}
+
+		if len(notFound) > 0 {
+			lines = append(lines, fmt.Sprintf("Tags not found: %s", strings.Join(notFound, " ")))
+		}
+
+		return c.Reply(strings.Join(lines, "\n"))
+	})
+
+	b.Handle("/"+UNSUBSCRIBE_COMMAND_NAME, func(c telebot.Context) error {
+		if c.Chat().Type != telebot.ChatPrivate {
+			return c.Reply("Send this command in private messages to the bot.")
+		}
+
+		tags, err := userSubscribedTags(app, c.Sender().ID)
+		if err != nil {
+			return err
+		}
+
+		args := map[string]bool{}
+		for _, arg := range c.Args() {
+			args[strings.TrimPrefix(arg, "#")] = true
+		}
+
+		unsubscribed := []teleblog.Tag{}
+
+		for _, tag := range tags {
+			// # Without args unsubscribe from all tags
+			if len(args) > 0 && !args[tag.Value] {
+				continue
+			}
+
+			_, err := app.DB().Delete(
+				(&teleblog.TagSubscription{}).TableName(),
+				dbx.HashExp{"tg_user_id": c.Sender().ID, "tag_id": tag.Id},
+			).Execute()
+			if err != nil {
+				return err
+			}
+
+			unsubscribed = append(unsubscribed, tag)
+		}
+
+		if len(unsubscribed) == 0 {
+			return c.Reply("You are not subscribed to these tags.")
+		}
+
+		return c.Reply(fmt.Sprintf("You will not get new posts with %s anymore.", formatTags(unsubscribed)))
+	})
+}
@@ -10,7 +10,58 @@ import (
 	"gopkg.in/telebot.v4"
 )
 
-func VerifyTokenCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
+// verifyToken binds sender Telegram account to the user of the token
+// and returns feedback
+func verifyToken(app *pocketbase.PocketBase, receivedToken string, tokenTTL time.Duration, sender *telebot.User) (string, error) {
+	token := &teleblog.TgVerificationToken{}
+
+	err := teleblog.TgVerificationTokenQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"value": receivedToken}).
+		Limit(1).
+		One(token)
+
+	if err != nil {
+		return "Token not found.", nil
+	}
+
+	if token.Verified {
+		return "Token already verified.", nil
+	}
+
+	if token.IsExpired(tokenTTL) {
+		return "Token expired.", nil
+	}
+
+	user := &teleblog.User{}
+
+	err = teleblog.UserQuery(app.Dao()).
+		AndWhere(dbx.HashExp{"id": token.UserId}).
+		Limit(1).
+		One(user)
+
+	if err != nil {
+		return "User not found.", nil
+	}
+
+	used, err := teleblog.UseTgVerificationToken(app.Dao(), token)
+	if err != nil {
+		return "", err
+	}
+
+	if !used {
+		return "Token already verified.", nil
+	}
+
+	user.TgUserId = sender.ID
+	user.TgUsername = sender.Username
+	if err := app.Dao().Save(user); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Successfully verified. Now add the bot to your channel administrators or send /%s.", ADD_CHANNEL_COMMAND_NAME), nil
+}
+
+func VerifyTokenCommand(b *telebot.Bot, app *pocketbase.PocketBase, tokenTTL time.Duration) {
 	b.Handle("/"+VERIFY_TOKEN_COMMAND_NAME, func(c telebot.Context) error {
 		tags := c.Args() // list of arguments splitted by a space
 
@@ -22,49 +73,25 @@ func VerifyTokenCommand(b *telebot.Bot, app *pocketbase.PocketBase) {
 			return c.Reply("Send only one token.")
 		}
 
-		receivedToken := tags[0]
-
-		token := &teleblog.TgVerificationToken{}
-
-		err := teleblog.TgVerificationTokenQuery(app.Dao()).
-			AndWhere(dbx.HashExp{"value": receivedToken}).
-			Limit(1).
-			One(token)
-
+		reply, err := verifyToken(app, tags[0], tokenTTL, c.Sender())
 		if err != nil {
-			return c.Reply("Token not found.")
+			return err
 		}
 
-		if token.Verified {
-			return c.Reply("Token already verified.")
-		}
+		return c.Reply(reply)
+	})
 
-		if token.Created.Time().Add(15 * time.Minute).Before(time.Now()) {
-			return c.Reply("Token expired.")
+	// # Deep link t.me/BOT?start=TOKEN
+	b.Handle("/start", func(c telebot.Context) error {
+		if c.Message().Payload == "" {
+			return c.Reply("Hello! This is teleblog bot. Add it to your channel and get posts in your blog.")
 		}
 
-		user := &teleblog.User{}
-
-		err = teleblog.UserQuery(app.Dao()).
-			AndWhere(dbx.HashExp{"id": token.UserId}).
-			Limit(1).
-			One(user)
-
+		reply, err := verifyToken(app, c.Message().Payload, tokenTTL, c.Sender())
 		if err != nil {
-			return c.Reply("User not found.")
-		}
-
-		token.Verified = true
-		if err := app.Dao().Save(token); err != nil {
-			return err
-		}
-
-		user.TgUserId = c.Sender().ID
-		user.TgUsername = c.Sender().Username
-		if err := app.Dao().Save(user); err != nil {
 			return err
 		}
 
-		return c.Reply("Successfully verified")
+		return c.Reply(reply)
 	})
 }
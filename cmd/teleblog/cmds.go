@@ -7,15 +7,23 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/Dionid/teleblog/cmd/teleblog/botapi"
 	"github.com/Dionid/teleblog/cmd/teleblog/features"
 	"github.com/Dionid/teleblog/libs/file"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/security"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/telebot.v4"
 )
 
-func AdditionalCommands(app *pocketbase.PocketBase) {
+func AdditionalCommands(app *pocketbase.PocketBase, config *Config) {
 	app.RootCmd.AddCommand(&cobra.Command{
 		Use:   "reset-password",
 		Short: "Reset admin password",
@@ -119,4 +127,311 @@ func AdditionalCommands(app *pocketbase.PocketBase) {
 			app.Logger().Info("Done")
 		},
 	})
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "rescore-spam",
+		Short: "Retrain spam classifier on labeled comments and score all comments",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			err := features.RescoreAllComments(app, config.SpamThreshold)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			app.Logger().Info("Done")
+		},
+	})
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "sync-chats",
+		Short: "Refresh title, username, photo and linked chat of all chats from Telegram",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			b, err := telebot.NewBot(telebot.Settings{
+				Token: config.TelegramBotToken,
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = botapi.SyncAllChats(b, app)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			app.Logger().Info("Done")
+		},
+	})
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "detect-gaps",
+		Short: "Find channel posts missed while bot was down and notify owners",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			chatsGaps, err := features.DetectAllPostGaps(app)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, chatGaps := range chatsGaps {
+				fmt.Printf("%s (%d): %s\n", chatGaps.Chat.TgTitle, chatGaps.Chat.TgChatId, teleblog.FormatPostGaps(chatGaps.Gaps))
+			}
+
+			app.Logger().Info("Done")
+		},
+	})
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "reparse",
+		Short: "Parse unparsable posts again and show errors of ones still failing",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			fixed, err := features.ReparseUnparsablePosts(app)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Parsed posts: %d\n", fixed)
+
+			groups, err := features.UnparsablePostsReport(app)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, group := range groups {
+				reason := group.Reason
+				if reason == "" {
+					reason = "reason is not stored"
+				}
+
+				fmt.Printf("Still unparsable: %d – %s\n", group.Count, reason)
+			}
+
+			app.Logger().Info("Done")
+		},
+	})
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "relink-comments",
+		Short: "Link comments without post to their posts and show ones not found",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			result, err := features.RelinkAllComments(app)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Linked comments: %d\n", result.Linked)
+
+			for _, comment := range result.Unresolved {
+				fmt.Printf(
+					"Not linked: comment %s (chat %s, tg message %d, reply to %d)\n",
+					comment.Id,
+					comment.ChatId,
+					comment.TgMessageId,
+					comment.TgReplyToMessageId,
+				)
+			}
+
+			app.Logger().Info("Done")
+		},
+	})
+
+	doctorFix := false
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check database consistency, repair what is safe with --fix",
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			checks, err := features.Doctor(app, doctorFix)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, check := range checks {
+				status := "ok"
+
+				if len(check.Issues) > 0 {
+					switch {
+					case doctorFix && check.Fixable:
+						status = fmt.Sprintf("found %d, fixed %d", len(check.Issues), check.Fixed)
+					case check.Fixable:
+						status = fmt.Sprintf("found %d, run with --fix to repair", len(check.Issues))
+					default:
+						status = fmt.Sprintf("found %d, can't be fixed automatically", len(check.Issues))
+					}
+				}
+
+				fmt.Printf("[%s] %s\n", check.Name, status)
+
+				for _, issue := range check.Issues {
+					fmt.Printf("  - %s\n", issue)
+				}
+			}
+
+			app.Logger().Info("Done")
+		},
+	}
+
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "repair found issues")
+
+	app.RootCmd.AddCommand(doctorCmd)
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "init",
+		Short: "Create admin and blog owner with random password and print Telegram verification link",
+		Long:  "Create admin and blog owner with random password and print Telegram verification link. Usage: init [admin-email]",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			email := args[0]
+			password := security.RandomString(20)
+
+			// # Admin
+			if _, err := app.Dao().FindAdminByEmail(email); err == nil {
+				log.Fatalf("Admin with email %s already exists", email)
+			}
+
+			admin := &models.Admin{}
+			admin.Email = email
+
+			if err := admin.SetPassword(password); err != nil {
+				log.Fatal(err)
+			}
+
+			if err := app.Dao().SaveAdmin(admin); err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Admin: %s\nPassword: %s\n", email, password)
+
+			// # Owner
+			user := &teleblog.User{}
+
+			err := teleblog.UserQuery(app.Dao()).
+				OrderBy("created asc").
+				Limit(1).
+				One(user)
+			if err != nil {
+				if !strings.Contains(err.Error(), "no rows") {
+					log.Fatal(err)
+				}
+
+				hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				user = &teleblog.User{
+					Username:     "owner",
+					Email:        email,
+					Verified:     true,
+					PasswordHash: string(hashedPassword),
+					TokenKey:     security.RandomString(50),
+				}
+
+				if err := app.Dao().Save(user); err != nil {
+					log.Fatal(err)
+				}
+
+				fmt.Printf("Owner: %s\nOwner password: %s\n", user.Username, password)
+			} else {
+				// # Existing owner keeps the password
+				fmt.Printf("Owner: %s (already exists, password is not changed)\n", user.Username)
+			}
+
+			// # Telegram verification
+			token, err := teleblog.NewTgVerificationToken(app.Dao(), user.Id)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			b, err := telebot.NewBot(telebot.Settings{
+				Token: config.TelegramBotToken,
+			})
+			if err != nil {
+				app.Logger().Warn("Can't get bot info", "error", err)
+				fmt.Printf("Send to the bot: /%s %s\n", botapi.VERIFY_TOKEN_COMMAND_NAME, token.Value)
+			} else {
+				fmt.Printf("Open to bind Telegram: %s\n", teleblog.TgVerificationDeepLink(b.Me.Username, token))
+			}
+
+			fmt.Printf("Link expires in %s\n", config.VerificationTokenTTL)
+
+			app.Logger().Info("Done")
+		},
+	})
+
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "verification-link",
+		Short: "Print Telegram verification link for the user",
+		Long:  "Print Telegram verification link for the user. Usage: verification-link [username]",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			defer (func() {
+				if r := recover(); r != nil {
+					log.Fatal("recover", r)
+				}
+			})()
+
+			user := &teleblog.User{}
+
+			err := teleblog.UserQuery(app.Dao()).
+				Where(dbx.HashExp{"username": args[0]}).
+				Limit(1).
+				One(user)
+			if err != nil {
+				log.Fatalf("Failed to find user %s: %v", args[0], err)
+			}
+
+			b, err := telebot.NewBot(telebot.Settings{
+				Token: config.TelegramBotToken,
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			token, err := teleblog.NewTgVerificationToken(app.Dao(), user.Id)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("%s\nLink expires in %s\n", teleblog.TgVerificationDeepLink(b.Me.Username, token), config.VerificationTokenTTL)
+		},
+	})
 }
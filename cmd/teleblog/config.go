@@ -3,18 +3,25 @@ package main
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Env                string `mapstructure:"ENV"`
-	Port               int    `mapstructure:"PORT"`
-	AppVersion         string `mapstructure:"APP_VERSION"`
-	TelegramBotToken   string `mapstructure:"TELEGRAM_BOT_TOKEN"`
-	DisableBot         bool   `mapstructure:"DISABLE_BOT"`
-	DisablePrepareDB   bool   `mapstructure:"DISABLE_PREPARE_DB"`
-	TelegramBotVerbose bool   `mapstructure:"TELEGRAM_BOT_VERBOSE"`
+	Env                string  `mapstructure:"ENV"`
+	Port               int     `mapstructure:"PORT"`
+	AppVersion         string  `mapstructure:"APP_VERSION"`
+	TelegramBotToken   string  `mapstructure:"TELEGRAM_BOT_TOKEN"`
+	DisableBot         bool    `mapstructure:"DISABLE_BOT"`
+	DisablePrepareDB   bool    `mapstructure:"DISABLE_PREPARE_DB"`
+	TelegramBotVerbose bool    `mapstructure:"TELEGRAM_BOT_VERBOSE"`
+	SpamThreshold      float64 `mapstructure:"SPAM_THRESHOLD"`
+
+	VerificationTokenTTL time.Duration `mapstructure:"VERIFICATION_TOKEN_TTL"`
+	NotificationInterval time.Duration `mapstructure:"NOTIFICATION_INTERVAL"`
+	SchedulerInterval    time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	ScheduleTimezone     string        `mapstructure:"SCHEDULE_TIMEZONE"`
 }
 
 // Call to load the variables from env
@@ -26,6 +33,11 @@ func initConfig() (*Config, error) {
 	viper.AddConfigPath(".")
 
 	viper.SetDefault("PORT", 8080)
+	viper.SetDefault("SPAM_THRESHOLD", 0.9)
+	viper.SetDefault("VERIFICATION_TOKEN_TTL", "15m")
+	viper.SetDefault("NOTIFICATION_INTERVAL", "10m")
+	viper.SetDefault("SCHEDULER_INTERVAL", "30s")
+	viper.SetDefault("SCHEDULE_TIMEZONE", "UTC")
 
 	// # Tell viper the name of your file
 	viper.SetConfigName("app")
@@ -0,0 +1,193 @@
+package features
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// ModerateComment recalculates filtered flag of the comment by its chat rules
+func ModerateComment(dao *daos.Dao, commentId string) error {
+	comment := &teleblog.Comment{}
+
+	err := teleblog.CommentQuery(dao).
+		Where(dbx.HashExp{"id": commentId}).
+		Limit(1).
+		One(comment)
+	if err != nil {
+		return fmt.Errorf("ModerateComment: get comment error: %w", err)
+	}
+
+	chat := &teleblog.Chat{}
+
+	err = teleblog.ChatQuery(dao).
+		Where(dbx.HashExp{"id": comment.ChatId}).
+		Limit(1).
+		One(chat)
+	if err != nil {
+		return fmt.Errorf("ModerateComment: get chat error: %w", err)
+	}
+
+	moderation, err := teleblog.NewCommentModeration(chat)
+	if err != nil {
+		return err
+	}
+
+	filtered := moderation.IsFiltered(comment)
+	if filtered == comment.Filtered {
+		return nil
+	}
+
+	_, err = dao.DB().Update(
+		comment.TableName(),
+		dbx.Params{"filtered": filtered},
+		dbx.HashExp{"id": comment.Id},
+	).Execute()
+
+	return err
+}
+
+// chatModeration returns moderation settings of the chat saved as model
+// or as record, nil is returned for other models
+func chatModeration(model models.Model) (*teleblog.Chat, error) {
+	chat := &teleblog.Chat{}
+
+	switch m := model.(type) {
+	case *teleblog.Chat:
+		chat = m
+	case *models.Record:
+		if m.Collection().Name != chat.TableName() {
+			return nil, nil
+		}
+
+		chat.HideBotComments = m.GetBool("hide_bot_comments")
+
+		if err := m.UnmarshalJSONField("comment_blocked_words", &chat.CommentBlockedWords); err != nil {
+			return nil, err
+		}
+
+		if err := m.UnmarshalJSONField("comment_blocked_regexps", &chat.CommentBlockedRegexps); err != nil {
+			return nil, err
+		}
+
+		if err := m.UnmarshalJSONField("comment_blocked_tg_user_ids", &chat.CommentBlockedTgUserIds); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	return chat, nil
+}
+
+// chatModerationChanged checks if moderation settings of the chats differ
+func chatModerationChanged(a *teleblog.Chat, b *teleblog.Chat) bool {
+	return a.HideBotComments != b.HideBotComments ||
+		!slices.Equal(a.CommentBlockedWords, b.CommentBlockedWords) ||
+		!slices.Equal(a.CommentBlockedRegexps, b.CommentBlockedRegexps) ||
+		!slices.Equal(a.CommentBlockedTgUserIds, b.CommentBlockedTgUserIds)
+}
+
+// validateChatModeration doesn't allow to save chat with broken regexps
+func validateChatModeration(e *core.ModelEvent) error {
+	chat, err := chatModeration(e.Model)
+	if err != nil || chat == nil {
+		return err
+	}
+
+	_, err = teleblog.NewCommentModeration(chat)
+
+	return err
+}
+
+// # Ids of chats which moderation settings are changed by the current update
+var chatModerationUpdates = struct {
+	sync.Mutex
+	ids map[string]bool
+}{
+	ids: map[string]bool{},
+}
+
+// InitCommentModeration keeps comments filtered flag in sync
+// with the chat moderation rules
+func InitCommentModeration(app *pocketbase.PocketBase) {
+	chatTableName := (&teleblog.Chat{}).TableName()
+	commentTableName := (&teleblog.Comment{}).TableName()
+
+	app.OnModelBeforeCreate(chatTableName).Add(validateChatModeration)
+
+	app.OnModelBeforeUpdate(chatTableName).Add(func(e *core.ModelEvent) error {
+		if err := validateChatModeration(e); err != nil {
+			return err
+		}
+
+		chat, err := chatModeration(e.Model)
+		if err != nil || chat == nil {
+			return err
+		}
+
+		oldChat := &teleblog.Chat{}
+
+		err = teleblog.ChatQuery(e.Dao).
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Limit(1).
+			One(oldChat)
+		if err != nil {
+			return fmt.Errorf("InitCommentModeration: get old chat error: %w", err)
+		}
+
+		// # Metadata sync and other saves don't need comments re-moderation
+		if !chatModerationChanged(chat, oldChat) {
+			return nil
+		}
+
+		chatModerationUpdates.Lock()
+		chatModerationUpdates.ids[e.Model.GetId()] = true
+		chatModerationUpdates.Unlock()
+
+		return nil
+	})
+
+	app.OnModelAfterUpdate(chatTableName).Add(func(e *core.ModelEvent) error {
+		chatModerationUpdates.Lock()
+		changed := chatModerationUpdates.ids[e.Model.GetId()]
+		delete(chatModerationUpdates.ids, e.Model.GetId())
+		chatModerationUpdates.Unlock()
+
+		if !changed {
+			return nil
+		}
+
+		chat := &teleblog.Chat{}
+
+		err := teleblog.ChatQuery(e.Dao).
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Limit(1).
+			One(chat)
+		if err != nil {
+			return fmt.Errorf("InitCommentModeration: get chat error: %w", err)
+		}
+
+		return teleblog.ApplyCommentModeration(e.Dao, chat)
+	})
+
+	moderateComment := func(e *core.ModelEvent) error {
+		err := ModerateComment(e.Dao, e.Model.GetId())
+		if err != nil {
+			// # Don't break comments saving because of moderation
+			app.Logger().Error("Error while moderating comment", "error", err, "comment_id", e.Model.GetId())
+		}
+
+		return nil
+	}
+
+	app.OnModelAfterCreate(commentTableName).Add(moderateComment)
+	app.OnModelAfterUpdate(commentTableName).Add(moderateComment)
+}
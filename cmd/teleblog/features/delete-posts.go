@@ -0,0 +1,116 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// DeletePosts deletes posts with their comments, tags and files.
+// Album is shown as one post, so other posts of albums are deleted too.
+// Files are removed after records, so they are kept if deleting fails.
+func DeletePosts(app core.App, postIds []string) error {
+	selectedIds := []any{}
+	for _, postId := range postIds {
+		selectedIds = append(selectedIds, postId)
+	}
+
+	selectedPosts := []*teleblog.Post{}
+
+	err := teleblog.PostQuery(app.Dao()).
+		Where(dbx.In("id", selectedIds...)).
+		All(&selectedPosts)
+	if err != nil {
+		return fmt.Errorf("DeletePosts: get posts error: %w", err)
+	}
+
+	albumPostIds := []string{}
+	seenIds := map[string]bool{}
+
+	for _, post := range selectedPosts {
+		albumIds := []string{post.Id}
+
+		if post.AlbumID != "" {
+			err := app.Dao().DB().
+				Select("id").
+				From("post").
+				Where(dbx.HashExp{"chat_id": post.ChatId, "album_id": post.AlbumID}).
+				Column(&albumIds)
+			if err != nil {
+				return fmt.Errorf("DeletePosts: get album posts error: %w", err)
+			}
+		}
+
+		for _, postId := range albumIds {
+			if !seenIds[postId] {
+				seenIds[postId] = true
+				albumPostIds = append(albumPostIds, postId)
+			}
+		}
+	}
+
+	ids := []any{}
+	for _, postId := range albumPostIds {
+		ids = append(ids, postId)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	commentIds := []string{}
+
+	err = app.Dao().DB().
+		Select("id").
+		From("comment").
+		Where(dbx.In("post_id", ids...)).
+		Column(&commentIds)
+	if err != nil {
+		return fmt.Errorf("DeletePosts: get comments error: %w", err)
+	}
+
+	err = app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		for _, tableName := range []string{"post_tag", "post_slug_redirect", "comment"} {
+			_, err := txDao.DB().Delete(tableName, dbx.In("post_id", ids...)).Execute()
+			if err != nil {
+				return fmt.Errorf("DeletePosts: delete %s error: %w", tableName, err)
+			}
+		}
+
+		_, err := txDao.DB().Delete("post", dbx.In("id", ids...)).Execute()
+		if err != nil {
+			return fmt.Errorf("DeletePosts: delete post error: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := deleteRecordsFiles(app, "post", albumPostIds); err != nil {
+		return fmt.Errorf("DeletePosts: delete post files error: %w", err)
+	}
+
+	if err := deleteRecordsFiles(app, "comment", commentIds); err != nil {
+		return fmt.Errorf("DeletePosts: delete comment files error: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteComment deletes the comment with its files
+func DeleteComment(app core.App, comment *teleblog.Comment) error {
+	if err := app.Dao().Delete(comment); err != nil {
+		return err
+	}
+
+	if err := deleteRecordsFiles(app, comment.TableName(), []string{comment.Id}); err != nil {
+		return fmt.Errorf("DeleteComment: delete files error: %w", err)
+	}
+
+	return nil
+}
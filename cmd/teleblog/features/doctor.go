@@ -0,0 +1,280 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// DoctorCheck is result of one consistency check of the database
+type DoctorCheck struct {
+	Name string
+	// Can be repaired with --fix
+	Fixable bool
+	Issues  []string
+	Fixed   int
+}
+
+type doctorCheckFunc func(app core.App, fix bool) (*DoctorCheck, error)
+
+// doctorOrphanPostTags finds post tags which post or tag is deleted
+func doctorOrphanPostTags(app core.App, fix bool) (*DoctorCheck, error) {
+	check := &DoctorCheck{Name: "post_tag rows of deleted posts or tags", Fixable: true}
+
+	rows := []struct {
+		Id     string `db:"id"`
+		PostId string `db:"post_id"`
+		TagId  string `db:"tag_id"`
+	}{}
+
+	err := app.Dao().DB().
+		Select("post_tag.id", "post_tag.post_id", "post_tag.tag_id").
+		From("post_tag").
+		LeftJoin("post", dbx.NewExp("post.id = post_tag.post_id")).
+		LeftJoin("tag", dbx.NewExp("tag.id = post_tag.tag_id")).
+		Where(dbx.NewExp("post.id IS NULL OR tag.id IS NULL")).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []any{}
+
+	for _, row := range rows {
+		check.Issues = append(check.Issues, fmt.Sprintf("post_tag %s (post %s, tag %s)", row.Id, row.PostId, row.TagId))
+		ids = append(ids, row.Id)
+	}
+
+	if fix && len(ids) > 0 {
+		_, err := app.DB().Delete("post_tag", dbx.In("id", ids...)).Execute()
+		if err != nil {
+			return nil, err
+		}
+
+		check.Fixed = len(ids)
+	}
+
+	return check, nil
+}
+
+// doctorCommentsWithoutPost finds comments not linked to existing posts
+// and links them if their posts can be found
+func doctorCommentsWithoutPost(app core.App, fix bool) (*DoctorCheck, error) {
+	check := &DoctorCheck{Name: "comments without post", Fixable: true}
+
+	comments := []*teleblog.Comment{}
+
+	err := teleblog.CommentQuery(app.Dao()).
+		LeftJoin("post", dbx.NewExp("post.id = comment.post_id")).
+		Where(dbx.NewExp("post.id IS NULL")).
+		All(&comments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range comments {
+		check.Issues = append(check.Issues, fmt.Sprintf(
+			"comment %s (chat %s, tg message %d, reply to %d, post %q)",
+			comment.Id,
+			comment.ChatId,
+			comment.TgMessageId,
+			comment.TgReplyToMessageId,
+			comment.PostId,
+		))
+	}
+
+	if fix && len(comments) > 0 {
+		result, err := RelinkAllComments(app)
+		if err != nil {
+			return nil, err
+		}
+
+		check.Fixed = result.Linked
+	}
+
+	return check, nil
+}
+
+// doctorDuplicateSlugs finds posts with the same slug, only one of them
+// is shown by the slug, so others get their id appended
+func doctorDuplicateSlugs(app core.App, fix bool) (*DoctorCheck, error) {
+	check := &DoctorCheck{Name: "posts with duplicate slugs", Fixable: true}
+
+	slugs := []string{}
+
+	err := app.Dao().DB().
+		Select("slug").
+		From("post").
+		Where(dbx.NewExp(`slug != ''`)).
+		GroupBy("slug").
+		Having(dbx.NewExp("count(*) > 1")).
+		Column(&slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, postSlug := range slugs {
+		posts := []*teleblog.Post{}
+
+		err := teleblog.PostQuery(app.Dao()).
+			Where(dbx.HashExp{"slug": postSlug}).
+			OrderBy("created asc").
+			All(&posts)
+		if err != nil {
+			return nil, err
+		}
+
+		// # The oldest post keeps its slug
+		for _, post := range posts[1:] {
+			check.Issues = append(check.Issues, fmt.Sprintf("post %s has slug %q of post %s", post.Id, postSlug, posts[0].Id))
+
+			if !fix {
+				continue
+			}
+
+			_, err := app.DB().Update(
+				post.TableName(),
+				dbx.Params{"slug": postSlug + "-" + post.Id},
+				dbx.HashExp{"id": post.Id},
+			).Execute()
+			if err != nil {
+				return nil, err
+			}
+
+			check.Fixed++
+		}
+	}
+
+	return check, nil
+}
+
+// doctorMissingMedia finds media of posts and comments which files
+// are missing from the storage and removes them from the records
+func doctorMissingMedia(app core.App, fix bool) (*DoctorCheck, error) {
+	check := &DoctorCheck{Name: "media without files", Fixable: true}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+
+	for _, collectionName := range []string{"post", "comment"} {
+		collection, err := app.Dao().FindCollectionByNameOrId(collectionName)
+		if err != nil {
+			return nil, err
+		}
+
+		records := []struct {
+			Id    string                  `db:"id"`
+			Media types.JsonArray[string] `db:"media"`
+		}{}
+
+		err = app.Dao().DB().
+			Select("id", "media").
+			From(collectionName).
+			Where(dbx.NewExp("json_array_length(media) > 0")).
+			All(&records)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			kept := types.JsonArray[string]{}
+
+			for _, name := range record.Media {
+				exists, err := fsys.Exists(collection.Id + "/" + record.Id + "/" + name)
+				if err != nil {
+					return nil, err
+				}
+
+				if exists {
+					kept = append(kept, name)
+					continue
+				}
+
+				check.Issues = append(check.Issues, fmt.Sprintf("%s %s: %s", collectionName, record.Id, name))
+			}
+
+			if !fix || len(kept) == len(record.Media) {
+				continue
+			}
+
+			_, err := app.DB().Update(
+				collectionName,
+				dbx.Params{"media": kept},
+				dbx.HashExp{"id": record.Id},
+			).Execute()
+			if err != nil {
+				return nil, err
+			}
+
+			check.Fixed += len(record.Media) - len(kept)
+		}
+	}
+
+	return check, nil
+}
+
+// doctorMissingLinkedChats finds chats linked to deleted chats,
+// Telegram id of the linked chat is kept, so sync can restore it
+func doctorMissingLinkedChats(app core.App, fix bool) (*DoctorCheck, error) {
+	check := &DoctorCheck{Name: "chats linked to deleted chats", Fixable: true}
+
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		LeftJoin("chat linked", dbx.NewExp("linked.id = chat.linked_chat_id")).
+		Where(dbx.NewExp(`chat.linked_chat_id != '' AND linked.id IS NULL`)).
+		All(&chats)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chat := range chats {
+		check.Issues = append(check.Issues, fmt.Sprintf("chat %s (%s) is linked to %s", chat.Id, chat.TgTitle, chat.LinkedChatId))
+
+		if !fix {
+			continue
+		}
+
+		_, err := app.DB().Update(
+			chat.TableName(),
+			dbx.Params{"linked_chat_id": ""},
+			dbx.HashExp{"id": chat.Id},
+		).Execute()
+		if err != nil {
+			return nil, err
+		}
+
+		check.Fixed++
+	}
+
+	return check, nil
+}
+
+// Doctor checks consistency of the database and with fix repairs
+// what can be repaired safely
+func Doctor(app core.App, fix bool) ([]*DoctorCheck, error) {
+	checks := []*DoctorCheck{}
+
+	for _, checkFunc := range []doctorCheckFunc{
+		doctorOrphanPostTags,
+		doctorCommentsWithoutPost,
+		doctorDuplicateSlugs,
+		doctorMissingMedia,
+		doctorMissingLinkedChats,
+	} {
+		check, err := checkFunc(app, fix)
+		if err != nil {
+			return checks, fmt.Errorf("Doctor: check error: %w", err)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
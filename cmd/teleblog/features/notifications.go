@@ -0,0 +1,199 @@
+package features
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"gopkg.in/telebot.v4"
+)
+
+// DEFAULT_NOTIFICATION_INTERVAL is used when configured interval is not positive
+const DEFAULT_NOTIFICATION_INTERVAL = 10 * time.Minute
+
+// # Notifications are sent only when the bot is running
+var notifier = struct {
+	sync.Mutex
+	bot      *telebot.Bot
+	interval time.Duration
+	// # user id + kind -> last sent time
+	sent map[string]time.Time
+	// # user id + kind -> notifications skipped by rate limit
+	skipped map[string]int
+	// # user id -> new comments count
+	newComments map[string]int
+}{
+	sent:        map[string]time.Time{},
+	skipped:     map[string]int{},
+	newComments: map[string]int{},
+}
+
+// NotifyUser sends DM to the verified user, if the kind is enabled.
+// Only one notification of each kind is sent per interval,
+// others are counted and reported with the next one.
+// It returns true if the notification is sent.
+func NotifyUser(app core.App, user *teleblog.User, kind string, text string) bool {
+	if user.TgUserId == 0 || !user.NotificationEnabled(kind) {
+		return false
+	}
+
+	notifier.Lock()
+
+	// # Bot isn't running (e.g. in CLI commands)
+	if notifier.bot == nil {
+		notifier.Unlock()
+		return false
+	}
+
+	key := user.Id + ":" + kind
+
+	if last, ok := notifier.sent[key]; ok && time.Since(last) < notifier.interval {
+		notifier.skipped[key]++
+		notifier.Unlock()
+		return false
+	}
+
+	if skipped := notifier.skipped[key]; skipped > 0 {
+		text += fmt.Sprintf("\n\n(+%d similar notifications were skipped)", skipped)
+	}
+
+	notifier.sent[key] = time.Now()
+	notifier.skipped[key] = 0
+
+	b := notifier.bot
+
+	notifier.Unlock()
+
+	_, err := b.Send(&telebot.User{ID: user.TgUserId}, text)
+	if err != nil {
+		// # Owner may have blocked the bot
+		app.Logger().Error("Error while sending notification", "error", err, "user_id", user.Id, "kind", kind)
+		return false
+	}
+
+	return true
+}
+
+// NotifyChatOwner sends notification to the owner of the chat,
+// it returns true if the notification is sent
+func NotifyChatOwner(app core.App, chatId string, kind string, text string) bool {
+	user := &teleblog.User{}
+
+	err := teleblog.UserQuery(app.Dao()).
+		InnerJoin("chat", dbx.NewExp("chat.user_id = users.id")).
+		Where(dbx.HashExp{"chat.id": chatId}).
+		Limit(1).
+		One(user)
+	if err != nil {
+		app.Logger().Error("Error while getting chat owner", "error", err, "chat_id", chatId)
+		return false
+	}
+
+	return NotifyUser(app, user, kind, text)
+}
+
+// NotifyTgChatOwners sends notification to owners of all records
+// of the Telegram chat
+func NotifyTgChatOwners(app core.App, tgChatId int64, kind string, text string) {
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		Where(dbx.HashExp{"tg_chat_id": tgChatId}).
+		All(&chats)
+	if err != nil {
+		app.Logger().Error("Error while getting chats", "error", err, "tg_chat_id", tgChatId)
+		return
+	}
+
+	for _, chat := range chats {
+		NotifyChatOwner(app, chat.Id, kind, text)
+	}
+}
+
+// sendNewCommentsSummaries notifies owners about comments
+// collected since the previous summary
+func sendNewCommentsSummaries(app *pocketbase.PocketBase) {
+	notifier.Lock()
+	newComments := notifier.newComments
+	notifier.newComments = map[string]int{}
+	notifier.Unlock()
+
+	for userId, count := range newComments {
+		user := &teleblog.User{}
+
+		err := teleblog.UserQuery(app.Dao()).
+			Where(dbx.HashExp{"id": userId}).
+			Limit(1).
+			One(user)
+		if err != nil {
+			app.Logger().Error("Error while getting user", "error", err, "user_id", userId)
+			continue
+		}
+
+		NotifyUser(app, user, teleblog.NOTIFICATION_NEW_COMMENTS, fmt.Sprintf("%d new comments in your blog.", count))
+	}
+}
+
+// InitNotifications lets the bot DM owners about ingestion problems
+// and new comments
+func InitNotifications(app *pocketbase.PocketBase, b *telebot.Bot, interval time.Duration) {
+	if interval <= 0 {
+		app.Logger().Warn("Wrong NOTIFICATION_INTERVAL, default is used", "interval", interval, "default", DEFAULT_NOTIFICATION_INTERVAL)
+		interval = DEFAULT_NOTIFICATION_INTERVAL
+	}
+
+	notifier.Lock()
+	notifier.bot = b
+	notifier.interval = interval
+	notifier.Unlock()
+
+	// # Count new comments
+	app.OnModelAfterCreate((&teleblog.Comment{}).TableName()).Add(func(e *core.ModelEvent) error {
+		comment := &teleblog.Comment{}
+
+		err := teleblog.CommentQuery(e.Dao).
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Limit(1).
+			One(comment)
+		if err != nil {
+			app.Logger().Error("Error while getting comment", "error", err, "comment_id", e.Model.GetId())
+			return nil
+		}
+
+		// # History import has its own notification
+		if comment.IsTgHistoryMessage {
+			return nil
+		}
+
+		chat := &teleblog.Chat{}
+
+		err = teleblog.ChatQuery(e.Dao).
+			Where(dbx.HashExp{"id": comment.ChatId}).
+			Limit(1).
+			One(chat)
+		if err != nil {
+			app.Logger().Error("Error while getting comment chat", "error", err, "comment_id", comment.Id)
+			return nil
+		}
+
+		notifier.Lock()
+		notifier.newComments[chat.UserId]++
+		notifier.Unlock()
+
+		return nil
+	})
+
+	// # Comments summaries
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sendNewCommentsSummaries(app)
+		}
+	}()
+}
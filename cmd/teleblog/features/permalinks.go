@@ -0,0 +1,103 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// configPermalinkPattern returns permalink pattern of the config saved
+// as model or as record, false is returned for other models
+func configPermalinkPattern(model models.Model) (string, bool) {
+	switch m := model.(type) {
+	case *teleblog.Config:
+		return m.PermalinkPattern, true
+	case *models.Record:
+		if m.Collection().Name == (&teleblog.Config{}).TableName() {
+			return m.GetString("permalink_pattern"), true
+		}
+	}
+
+	return "", false
+}
+
+func addConfigOldPermalinkPattern(model models.Model, pattern string) error {
+	oldPatterns := []string{}
+
+	switch m := model.(type) {
+	case *teleblog.Config:
+		oldPatterns = m.OldPermalinkPatterns
+	case *models.Record:
+		if err := m.UnmarshalJSONField("old_permalink_patterns", &oldPatterns); err != nil {
+			return err
+		}
+	}
+
+	for _, oldPattern := range oldPatterns {
+		if oldPattern == pattern {
+			return nil
+		}
+	}
+
+	oldPatterns = append(oldPatterns, pattern)
+
+	switch m := model.(type) {
+	case *teleblog.Config:
+		m.OldPermalinkPatterns = oldPatterns
+	case *models.Record:
+		m.Set("old_permalink_patterns", oldPatterns)
+	}
+
+	return nil
+}
+
+// validatePermalinkPattern doesn't allow to save config with broken pattern
+func validatePermalinkPattern(e *core.ModelEvent) error {
+	pattern, ok := configPermalinkPattern(e.Model)
+	if !ok || pattern == "" {
+		return nil
+	}
+
+	return teleblog.ValidatePermalinkPattern(pattern)
+}
+
+// InitPermalinks validates permalink patterns of sites and remembers
+// old ones, so links of the old pattern are redirected
+func InitPermalinks(app *pocketbase.PocketBase) {
+	configTableName := (&teleblog.Config{}).TableName()
+
+	app.OnModelBeforeCreate(configTableName).Add(validatePermalinkPattern)
+
+	app.OnModelBeforeUpdate(configTableName).Add(func(e *core.ModelEvent) error {
+		if err := validatePermalinkPattern(e); err != nil {
+			return err
+		}
+
+		pattern, ok := configPermalinkPattern(e.Model)
+		if !ok {
+			return nil
+		}
+
+		oldPattern := ""
+
+		err := teleblog.ConfigQuery(e.Dao).
+			Select("permalink_pattern").
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Row(&oldPattern)
+		if err != nil && !strings.Contains(err.Error(), "no rows") {
+			return fmt.Errorf("InitPermalinks: get old pattern error: %w", err)
+		}
+
+		// # Default pattern is always redirected
+		if oldPattern == "" || oldPattern == teleblog.DefaultPermalinkPattern || oldPattern == pattern {
+			return nil
+		}
+
+		return addConfigOldPermalinkPattern(e.Model, oldPattern)
+	})
+}
@@ -0,0 +1,153 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// DetectPostGaps finds missed posts of the channel and asks the owner
+// to upload history export for new gaps. Gaps are stored as known only
+// if the owner is notified, so they are reported again otherwise.
+func DetectPostGaps(app core.App, chat *teleblog.Chat) ([]teleblog.PostGap, error) {
+	postIds := []int{}
+
+	err := app.Dao().DB().
+		Select("tg_post_id").
+		From((&teleblog.Post{}).TableName()).
+		Where(dbx.HashExp{"chat_id": chat.Id}).
+		AndWhere(dbx.NewExp("tg_post_id > 0")).
+		Column(&postIds)
+	if err != nil {
+		return nil, fmt.Errorf("DetectPostGaps: get posts ids error: %w", err)
+	}
+
+	gaps := teleblog.FindPostGaps(postIds, chat.TgSkippedPostIds)
+	newGaps := teleblog.NewPostGaps(chat.TgPostGaps, gaps)
+
+	knownGaps := gaps
+
+	if len(newGaps) > 0 {
+		app.Logger().Warn("Found missing posts", "chat_id", chat.Id, "gaps", teleblog.FormatPostGaps(newGaps))
+
+		sent := NotifyChatOwner(
+			app,
+			chat.Id,
+			teleblog.NOTIFICATION_POST_GAPS,
+			fmt.Sprintf(
+				"Posts %s of %s are missing (probably bot was down). Export channel history covering them in Telegram Desktop (JSON) and upload it in the admin panel (/_/upload-history), only missing posts will be imported.",
+				teleblog.FormatPostGaps(newGaps),
+				chat.TgTitle,
+			),
+		)
+
+		if !sent {
+			knownGaps = teleblog.KnownPostGaps(chat.TgPostGaps, gaps)
+		}
+	}
+
+	if slices.Equal(knownGaps, chat.TgPostGaps) {
+		return gaps, nil
+	}
+
+	jsonGaps, err := json.Marshal(knownGaps)
+	if err != nil {
+		return nil, err
+	}
+
+	// # Not through dao, because chat update reapplies comments moderation
+	_, err = app.DB().Update(
+		chat.TableName(),
+		dbx.Params{"tg_post_gaps": string(jsonGaps)},
+		dbx.HashExp{"id": chat.Id},
+	).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("DetectPostGaps: update chat error: %w", err)
+	}
+
+	chat.TgPostGaps = knownGaps
+
+	return gaps, nil
+}
+
+// ChatPostGaps are missed posts of the channel
+type ChatPostGaps struct {
+	Chat *teleblog.Chat
+	Gaps []teleblog.PostGap
+}
+
+// DetectAllPostGaps checks all channels for missed posts
+// and returns channels with gaps
+func DetectAllPostGaps(app core.App) ([]ChatPostGaps, error) {
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		Where(dbx.HashExp{"tg_type": []any{"channel", "privatechannel"}}).
+		All(&chats)
+	if err != nil {
+		return nil, fmt.Errorf("DetectAllPostGaps: get chats error: %w", err)
+	}
+
+	result := []ChatPostGaps{}
+
+	for _, chat := range chats {
+		gaps, err := DetectPostGaps(app, chat)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(gaps) > 0 {
+			result = append(result, ChatPostGaps{Chat: chat, Gaps: gaps})
+		}
+	}
+
+	return result, nil
+}
+
+// SkipPostIds marks channel message ids that are known not to be posts
+// (service or deleted messages), so they are not reported as gaps
+func SkipPostIds(app core.App, chat *teleblog.Chat, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	known := map[int]bool{}
+	for _, id := range chat.TgSkippedPostIds {
+		known[id] = true
+	}
+
+	skipped := chat.TgSkippedPostIds
+
+	for _, id := range ids {
+		if !known[id] {
+			known[id] = true
+			skipped = append(skipped, id)
+		}
+	}
+
+	if len(skipped) == len(chat.TgSkippedPostIds) {
+		return nil
+	}
+
+	jsonSkipped, err := json.Marshal(skipped)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.DB().Update(
+		chat.TableName(),
+		dbx.Params{"tg_skipped_post_ids": string(jsonSkipped)},
+		dbx.HashExp{"id": chat.Id},
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("SkipPostIds: update chat error: %w", err)
+	}
+
+	chat.TgSkippedPostIds = skipped
+
+	return nil
+}
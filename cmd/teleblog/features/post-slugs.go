@@ -0,0 +1,131 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// postSlug returns slug of the post saved as model or as record,
+// false is returned for other models
+func postSlug(model models.Model) (string, bool) {
+	switch m := model.(type) {
+	case *teleblog.Post:
+		return m.Slug, true
+	case *models.Record:
+		if m.Collection().Name == (&teleblog.Post{}).TableName() {
+			return m.GetString("slug"), true
+		}
+	}
+
+	return "", false
+}
+
+func setPostSlug(model models.Model, value string) {
+	switch m := model.(type) {
+	case *teleblog.Post:
+		m.Slug = value
+	case *models.Record:
+		m.Set("slug", value)
+	}
+}
+
+// savePostSlugRedirect remembers old slug of the post, so old links
+// are redirected to the new one
+func savePostSlugRedirect(dao *daos.Dao, postId string, oldSlug string) error {
+	_, err := dao.DB().Delete(
+		(&teleblog.PostSlugRedirect{}).TableName(),
+		dbx.HashExp{"slug": oldSlug},
+	).Execute()
+	if err != nil {
+		return err
+	}
+
+	return dao.Save(&teleblog.PostSlugRedirect{
+		PostId: postId,
+		Slug:   oldSlug,
+	})
+}
+
+// InitPostSlugs keeps slugs of posts unique and saves redirects
+// from old slugs when they are changed
+func InitPostSlugs(app *pocketbase.PocketBase) {
+	// ensureUniqueSlug suffixes slug of the post if it is taken and returns it
+	ensureUniqueSlug := func(e *core.ModelEvent) (string, bool, error) {
+		currentSlug, ok := postSlug(e.Model)
+		if !ok {
+			return "", false, nil
+		}
+
+		uniqueSlug, err := teleblog.UniquePostSlug(e.Dao, currentSlug, e.Model.GetId())
+		if err != nil {
+			return "", false, err
+		}
+
+		setPostSlug(e.Model, uniqueSlug)
+
+		return uniqueSlug, true, nil
+	}
+
+	app.OnModelBeforeCreate((&teleblog.Post{}).TableName()).Add(func(e *core.ModelEvent) error {
+		_, _, err := ensureUniqueSlug(e)
+		if err != nil {
+			return fmt.Errorf("InitPostSlugs: %w", err)
+		}
+
+		return nil
+	})
+
+	app.OnModelBeforeUpdate((&teleblog.Post{}).TableName()).Add(func(e *core.ModelEvent) error {
+		newSlug, ok, err := ensureUniqueSlug(e)
+		if err != nil {
+			return fmt.Errorf("InitPostSlugs: %w", err)
+		}
+
+		if !ok {
+			return nil
+		}
+
+		oldSlug := ""
+
+		err = teleblog.PostQuery(e.Dao).
+			Select("slug").
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Row(&oldSlug)
+		if err != nil && !strings.Contains(err.Error(), "no rows") {
+			return fmt.Errorf("InitPostSlugs: get old slug error: %w", err)
+		}
+
+		if oldSlug == newSlug {
+			return nil
+		}
+
+		// # New slug is not redirected anymore
+		if newSlug != "" {
+			_, err = e.Dao.DB().Delete(
+				(&teleblog.PostSlugRedirect{}).TableName(),
+				dbx.HashExp{"slug": newSlug},
+			).Execute()
+			if err != nil {
+				return fmt.Errorf("InitPostSlugs: delete redirect error: %w", err)
+			}
+		}
+
+		if oldSlug == "" {
+			return nil
+		}
+
+		err = savePostSlugRedirect(e.Dao, e.Model.GetId(), oldSlug)
+		if err != nil {
+			return fmt.Errorf("InitPostSlugs: save redirect error: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,250 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"gopkg.in/telebot.v4"
+)
+
+// Replies deeper than this are not followed to find the post
+const maxCommentReplyChainDepth = 100
+
+// RelinkCommentsResult is result of linking comments to their posts
+type RelinkCommentsResult struct {
+	Linked int
+	// Comments which post is not found
+	Unresolved []*teleblog.Comment
+}
+
+// CommentPostResolver finds posts of comments of the discussion group
+type CommentPostResolver struct {
+	app  core.App
+	chat *teleblog.Chat
+	// # tg message id in the group -> post id, for reply chains
+	resolved map[int]string
+}
+
+func NewCommentPostResolver(app core.App, chat *teleblog.Chat) *CommentPostResolver {
+	return &CommentPostResolver{
+		app:      app,
+		chat:     chat,
+		resolved: map[int]string{},
+	}
+}
+
+// postByGroupMessage finds post which was forwarded to the group as the message,
+// if the group is not linked to the channel (yet) posts of all chats are checked,
+// so it must be used only with thread ids
+func (r *CommentPostResolver) postByGroupMessage(tgGroupMessageId int) (string, error) {
+	post := teleblog.Post{}
+
+	exp := dbx.HashExp{"tg_group_message_id": tgGroupMessageId}
+	if r.chat.LinkedChatId != "" {
+		exp["chat_id"] = r.chat.LinkedChatId
+	}
+
+	err := teleblog.PostQuery(r.app.Dao()).
+		Where(exp).
+		Limit(1).
+		One(&post)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return post.Id, nil
+}
+
+// postByForward finds post by the channel message forwarded to the group
+// and remembers the forward, so thread replies are found by it later
+func (r *CommentPostResolver) postByForward(forward *telebot.Message) (string, error) {
+	if r.chat.LinkedChatId == "" || forward.OriginalChat == nil || forward.OriginalChat.ID != r.chat.TgLinkedChatId || forward.OriginalMessageID == 0 {
+		return "", nil
+	}
+
+	post := teleblog.Post{}
+
+	err := teleblog.PostQuery(r.app.Dao()).
+		Where(dbx.HashExp{"chat_id": r.chat.LinkedChatId, "tg_post_id": forward.OriginalMessageID}).
+		Limit(1).
+		One(&post)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	if post.TgGroupMessageId == 0 {
+		_, err := r.app.DB().Update(
+			post.TableName(),
+			dbx.Params{"tg_group_message_id": forward.ID},
+			dbx.HashExp{"id": post.Id},
+		).Execute()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return post.Id, nil
+}
+
+// Resolve returns id of the comment post or empty string if it is not found.
+// Post is found by thread id, forwarded channel post the comment replies to,
+// group message of the post or post of the replied comment.
+func (r *CommentPostResolver) Resolve(comment *teleblog.Comment) (string, error) {
+	return r.resolve(comment, 0)
+}
+
+func (r *CommentPostResolver) resolve(comment *teleblog.Comment, depth int) (string, error) {
+	if postId, ok := r.resolved[comment.TgMessageId]; ok && comment.TgMessageId != 0 {
+		return postId, nil
+	}
+
+	postId := ""
+
+	// # Thread and forwards are known only for messages received by the bot
+	if !comment.IsTgHistoryMessage {
+		rawMessage := telebot.Message{}
+
+		jb, err := comment.TgMessageRaw.MarshalJSON()
+		if err == nil {
+			err = json.Unmarshal(jb, &rawMessage)
+		}
+
+		if err == nil && rawMessage.ThreadID != 0 {
+			postId, err = r.postByGroupMessage(rawMessage.ThreadID)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if err == nil && postId == "" && rawMessage.ReplyTo != nil {
+			postId, err = r.postByForward(rawMessage.ReplyTo)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// # Replied message id is checked only in the linked channel posts
+	if postId == "" && comment.TgReplyToMessageId != 0 && r.chat.LinkedChatId != "" {
+		var err error
+
+		postId, err = r.postByGroupMessage(comment.TgReplyToMessageId)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// # Reply to other comment
+	if postId == "" && comment.TgReplyToMessageId != 0 && depth < maxCommentReplyChainDepth {
+		parent := &teleblog.Comment{}
+
+		err := teleblog.CommentQuery(r.app.Dao()).
+			Where(dbx.HashExp{"chat_id": r.chat.Id, "tg_comment_id": comment.TgReplyToMessageId}).
+			Limit(1).
+			One(parent)
+		if err != nil && !strings.Contains(err.Error(), "no rows") {
+			return "", err
+		}
+
+		if err == nil {
+			postId = parent.PostId
+
+			if postId == "" {
+				postId, err = r.resolve(parent, depth+1)
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	if comment.TgMessageId != 0 {
+		r.resolved[comment.TgMessageId] = postId
+	}
+
+	return postId, nil
+}
+
+// RelinkComments links comments of the group without post
+// (or with deleted one) to their posts
+func RelinkComments(app core.App, chat *teleblog.Chat) (*RelinkCommentsResult, error) {
+	result := &RelinkCommentsResult{}
+
+	comments := []*teleblog.Comment{}
+
+	err := teleblog.CommentQuery(app.Dao()).
+		LeftJoin("post", dbx.NewExp("post.id = comment.post_id")).
+		Where(dbx.HashExp{"comment.chat_id": chat.Id}).
+		AndWhere(dbx.NewExp("post.id IS NULL")).
+		OrderBy("comment.tg_comment_id asc").
+		All(&comments)
+	if err != nil {
+		return nil, fmt.Errorf("RelinkComments: get comments error: %w", err)
+	}
+
+	resolver := NewCommentPostResolver(app, chat)
+
+	for _, comment := range comments {
+		postId, err := resolver.Resolve(comment)
+		if err != nil {
+			return nil, fmt.Errorf("RelinkComments: resolve comment %s error: %w", comment.Id, err)
+		}
+
+		if postId == "" {
+			result.Unresolved = append(result.Unresolved, comment)
+			continue
+		}
+
+		_, err = app.DB().Update(
+			comment.TableName(),
+			dbx.Params{"post_id": postId},
+			dbx.HashExp{"id": comment.Id},
+		).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("RelinkComments: update comment error: %w", err)
+		}
+
+		comment.PostId = postId
+		result.Linked++
+	}
+
+	return result, nil
+}
+
+// RelinkAllComments links comments of all groups to their posts
+func RelinkAllComments(app core.App) (*RelinkCommentsResult, error) {
+	result := &RelinkCommentsResult{}
+
+	chats := []*teleblog.Chat{}
+
+	err := teleblog.ChatQuery(app.Dao()).
+		Where(dbx.NewExp("chat.id IN (SELECT DISTINCT chat_id FROM comment)")).
+		All(&chats)
+	if err != nil {
+		return nil, fmt.Errorf("RelinkAllComments: get chats error: %w", err)
+	}
+
+	for _, chat := range chats {
+		chatResult, err := RelinkComments(app, chat)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Linked += chatResult.Linked
+		result.Unresolved = append(result.Unresolved, chatResult.Unresolved...)
+	}
+
+	return result, nil
+}
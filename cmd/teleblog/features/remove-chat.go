@@ -0,0 +1,162 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// chatRecordIds returns ids of records of the collection that belong to the chats
+func chatRecordIds(app core.App, collectionName string, chatIds []any) ([]string, error) {
+	recordIds := []string{}
+
+	err := app.Dao().DB().
+		Select("id").
+		From(collectionName).
+		Where(dbx.In("chat_id", chatIds...)).
+		Column(&recordIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordIds, nil
+}
+
+// deleteRecordsFiles removes uploaded files of the collection records
+func deleteRecordsFiles(app core.App, collectionName string, recordIds []string) error {
+	collection, err := app.Dao().FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	for _, recordId := range recordIds {
+		if errs := fsys.DeletePrefix(collection.Id + "/" + recordId + "/"); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+
+	return nil
+}
+
+// RemoveChat deletes the chat with its linked group and scheduled posts.
+// With purge it also deletes their posts and comments, otherwise they
+// stay in the database, but are not shown until the chat is added again.
+// Files are removed after records, so they are kept if deleting fails.
+func RemoveChat(app core.App, chat *teleblog.Chat, purge bool) error {
+	chatIds := []any{chat.Id}
+
+	if chat.LinkedChatId != "" {
+		chatIds = append(chatIds, chat.LinkedChatId)
+	}
+
+	// # Collection name -> ids of records which files must be removed
+	filesRecordIds := map[string][]string{
+		chat.TableName(): {},
+	}
+
+	for _, chatId := range chatIds {
+		filesRecordIds[chat.TableName()] = append(filesRecordIds[chat.TableName()], chatId.(string))
+	}
+
+	collectionNames := []string{(&teleblog.ScheduledPost{}).TableName()}
+	if purge {
+		collectionNames = append(collectionNames, (&teleblog.Post{}).TableName(), (&teleblog.Comment{}).TableName())
+	}
+
+	for _, collectionName := range collectionNames {
+		recordIds, err := chatRecordIds(app, collectionName, chatIds)
+		if err != nil {
+			return fmt.Errorf("RemoveChat: get %s ids error: %w", collectionName, err)
+		}
+
+		filesRecordIds[collectionName] = recordIds
+	}
+
+	err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		if purge {
+			for _, tableName := range []string{"post_tag", "comment", "post"} {
+				_, err := txDao.DB().Delete(tableName, dbx.In("chat_id", chatIds...)).Execute()
+				if err != nil {
+					return fmt.Errorf("RemoveChat: delete %s error: %w", tableName, err)
+				}
+			}
+
+			_, err := txDao.DB().Delete("post_slug_redirect", dbx.NewExp("post_id NOT IN (SELECT id FROM post)")).Execute()
+			if err != nil {
+				return fmt.Errorf("RemoveChat: delete post_slug_redirect error: %w", err)
+			}
+
+			// # Tags are shared by channels, only unused ones are deleted
+			_, err = txDao.DB().Delete("tag", dbx.NewExp("id NOT IN (SELECT tag_id FROM post_tag)")).Execute()
+			if err != nil {
+				return fmt.Errorf("RemoveChat: delete tag error: %w", err)
+			}
+
+			_, err = txDao.DB().Delete("tag_subscription", dbx.NewExp("tag_id NOT IN (SELECT id FROM tag)")).Execute()
+			if err != nil {
+				return fmt.Errorf("RemoveChat: delete tag_subscription error: %w", err)
+			}
+		} else {
+			// # Added again chat gets the same id, so its posts are shown again
+			chats := []*teleblog.Chat{}
+
+			err := teleblog.ChatQuery(txDao).
+				Where(dbx.In("id", chatIds...)).
+				All(&chats)
+			if err != nil {
+				return fmt.Errorf("RemoveChat: get chats error: %w", err)
+			}
+
+			for _, removedChat := range chats {
+				_, err := txDao.DB().Delete(
+					(&teleblog.RemovedChat{}).TableName(),
+					dbx.HashExp{"user_id": removedChat.UserId, "tg_chat_id": removedChat.TgChatId},
+				).Execute()
+				if err != nil {
+					return fmt.Errorf("RemoveChat: delete old removed chat error: %w", err)
+				}
+
+				err = txDao.Save(&teleblog.RemovedChat{
+					UserId:   removedChat.UserId,
+					TgChatId: removedChat.TgChatId,
+					ChatId:   removedChat.Id,
+				})
+				if err != nil {
+					return fmt.Errorf("RemoveChat: save removed chat error: %w", err)
+				}
+			}
+		}
+
+		_, err := txDao.DB().Delete("scheduled_post", dbx.In("chat_id", chatIds...)).Execute()
+		if err != nil {
+			return fmt.Errorf("RemoveChat: delete scheduled_post error: %w", err)
+		}
+
+		_, err = txDao.DB().Delete(chat.TableName(), dbx.In("id", chatIds...)).Execute()
+		if err != nil {
+			return fmt.Errorf("RemoveChat: delete chat error: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for collectionName, recordIds := range filesRecordIds {
+		if err := deleteRecordsFiles(app, collectionName, recordIds); err != nil {
+			return fmt.Errorf("RemoveChat: delete %s files error: %w", collectionName, err)
+		}
+	}
+
+	return nil
+}
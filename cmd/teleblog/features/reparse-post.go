@@ -0,0 +1,130 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Dionid/teleblog/libs/slug"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"gopkg.in/telebot.v4"
+)
+
+// ReparsePost parses raw message of the post again with current
+// message structs and shows it on the blog if it succeeds, otherwise
+// post is marked unparsable with the reason and false is returned
+func ReparsePost(app *pocketbase.PocketBase, post *teleblog.Post) (bool, error) {
+	// # Web posts are rendered from markdown on every save
+	if post.IsWebPost() {
+		return true, nil
+	}
+
+	jb, err := post.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+
+	text := ""
+	albumId := ""
+	replyToTgPostId := 0
+
+	if post.IsTgHistoryMessage {
+		rawMessage := teleblog.HistoryMessage{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err == nil {
+			for _, entity := range rawMessage.TextEntities {
+				text += entity.Text
+			}
+
+			albumId = rawMessage.DateUnix
+			replyToTgPostId = rawMessage.ReplyToMessageId
+		}
+	} else {
+		rawMessage := telebot.Message{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err == nil {
+			text = rawMessage.Text + rawMessage.Caption
+
+			if rawMessage.AlbumID != "" {
+				albumId = rawMessage.AlbumID
+			} else {
+				albumId = strconv.Itoa(int(rawMessage.Unixtime))
+			}
+
+			if rawMessage.ReplyTo != nil {
+				replyToTgPostId = rawMessage.ReplyTo.ID
+			}
+		}
+	}
+
+	if err == nil {
+		_, err = teleblog.PostTextWithMarkup(*post)
+	}
+
+	if err != nil {
+		return false, MarkPostUnparsable(app, post, err)
+	}
+
+	post.Text = text
+	post.Unparsable = false
+	post.UnparsableReason = ""
+	post.TgReplyToPostId = replyToTgPostId
+
+	if post.AlbumID == "" {
+		post.AlbumID = albumId
+	}
+
+	// # Title, slug and SEO could be edited by the owner
+	if post.Title == "" {
+		post.Title = templu.RemoveNewLines(fmt.Sprintf("%.60s", text))
+	}
+
+	if post.SeoDescription == "" {
+		post.SeoDescription = templu.RemoveNewLines(fmt.Sprintf("%.160s", text))
+	}
+
+	if post.Slug == "" && text != "" {
+		post.Slug = slug.GenerateSlug(text, post.Created.Time())
+	}
+
+	err = app.Dao().Save(post)
+	if err != nil {
+		return false, fmt.Errorf("ReparsePost: save post error: %w", err)
+	}
+
+	return true, ExtractAndSavePostTags(app, *post)
+}
+
+// ReparseUnparsablePosts tries to parse all unparsable posts again
+// and returns how many of them are shown on the blog now
+func ReparseUnparsablePosts(app *pocketbase.PocketBase) (int, error) {
+	posts := []*teleblog.Post{}
+
+	err := teleblog.PostQuery(app.Dao()).
+		Where(dbx.HashExp{"unparsable": true}).
+		OrderBy("created asc").
+		All(&posts)
+	if err != nil {
+		return 0, fmt.Errorf("ReparseUnparsablePosts: get posts error: %w", err)
+	}
+
+	fixed := 0
+
+	for _, post := range posts {
+		ok, err := ReparsePost(app, post)
+		if err != nil {
+			return fixed, err
+		}
+
+		if ok {
+			fixed++
+		}
+	}
+
+	return fixed, nil
+}
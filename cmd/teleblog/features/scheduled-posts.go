@@ -0,0 +1,215 @@
+package features
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"gopkg.in/telebot.v4"
+)
+
+// scheduledPostMedia makes telebot media from the stored file,
+// caption is set only for the first media of the album
+func scheduledPostMedia(name string, file telebot.File, caption string) telebot.Inputtable {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return &telebot.Photo{File: file, Caption: caption}
+	case ".mp4", ".mov", ".webm":
+		return &telebot.Video{File: file, Caption: caption, FileName: name}
+	case ".mp3", ".m4a", ".ogg", ".oga":
+		return &telebot.Audio{File: file, Caption: caption, FileName: name}
+	default:
+		return &telebot.Document{File: file, Caption: caption, FileName: name}
+	}
+}
+
+// sendScheduledPost sends the post to the channel and returns id
+// of the channel message
+func sendScheduledPost(app core.App, b *telebot.Bot, scheduledPost *teleblog.ScheduledPost, chat *teleblog.Chat) (int, error) {
+	to := &telebot.Chat{ID: chat.TgChatId}
+
+	// # Draft sent to the bot keeps formatting and albums
+	if len(scheduledPost.TgSourceMessageIds) > 0 {
+		msgs := []telebot.Editable{}
+		for _, id := range scheduledPost.TgSourceMessageIds {
+			msgs = append(msgs, telebot.StoredMessage{
+				MessageID: strconv.Itoa(id),
+				ChatID:    scheduledPost.TgSourceChatId,
+			})
+		}
+
+		messages, err := b.CopyMany(to, msgs)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(messages) == 0 {
+			return 0, fmt.Errorf("draft messages were deleted from the chat with the bot")
+		}
+
+		return messages[0].ID, nil
+	}
+
+	if len(scheduledPost.Media) == 0 {
+		if scheduledPost.Text == "" {
+			return 0, fmt.Errorf("post has neither text nor media")
+		}
+
+		message, err := b.Send(to, scheduledPost.Text)
+		if err != nil {
+			return 0, err
+		}
+
+		return message.ID, nil
+	}
+
+	collection, err := app.Dao().FindCollectionByNameOrId(scheduledPost.TableName())
+	if err != nil {
+		return 0, err
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return 0, err
+	}
+	defer fsys.Close()
+
+	album := telebot.Album{}
+
+	for i, name := range scheduledPost.Media {
+		reader, err := fsys.GetFile(collection.Id + "/" + scheduledPost.Id + "/" + name)
+		if err != nil {
+			return 0, fmt.Errorf("get media %s error: %w", name, err)
+		}
+		defer reader.Close()
+
+		caption := ""
+		if i == 0 {
+			caption = scheduledPost.Text
+		}
+
+		album = append(album, scheduledPostMedia(name, telebot.FromReader(reader), caption))
+	}
+
+	if len(album) == 1 {
+		message, err := b.Send(to, album[0])
+		if err != nil {
+			return 0, err
+		}
+
+		return message.ID, nil
+	}
+
+	messages, err := b.SendAlbum(to, album)
+	if err != nil {
+		return 0, err
+	}
+
+	return messages[0].ID, nil
+}
+
+// PublishScheduledPosts sends posts which publish time has come
+// to their channels, the channel posts are then saved by the bot as usual
+func PublishScheduledPosts(app core.App, b *telebot.Bot) error {
+	scheduledPosts := []*teleblog.ScheduledPost{}
+
+	err := teleblog.ScheduledPostQuery(app.Dao()).
+		Where(dbx.HashExp{"status": teleblog.SCHEDULED_POST_STATUS_SCHEDULED}).
+		AndWhere(dbx.NewExp("publish_at <= {:now}", dbx.Params{"now": types.NowDateTime().String()})).
+		OrderBy("publish_at asc").
+		All(&scheduledPosts)
+	if err != nil {
+		return fmt.Errorf("PublishScheduledPosts: get scheduled posts error: %w", err)
+	}
+
+	for _, scheduledPost := range scheduledPosts {
+		chat := &teleblog.Chat{}
+
+		err := teleblog.ChatQuery(app.Dao()).
+			Where(dbx.HashExp{"id": scheduledPost.ChatId}).
+			Limit(1).
+			One(chat)
+		if err != nil {
+			app.Logger().Error("Error while getting scheduled post chat", "error", err, "scheduled_post_id", scheduledPost.Id)
+
+			// # Other posts must be published anyway
+			scheduledPost.Status = teleblog.SCHEDULED_POST_STATUS_FAILED
+			scheduledPost.Error = fmt.Sprintf("channel not found: %s", err)
+
+			if err := app.Dao().Save(scheduledPost); err != nil {
+				return fmt.Errorf("PublishScheduledPosts: save scheduled post error: %w", err)
+			}
+
+			continue
+		}
+
+		// # Claimed before sending, so the post is never sent twice
+		result, err := app.Dao().DB().Update(
+			scheduledPost.TableName(),
+			dbx.Params{"status": teleblog.SCHEDULED_POST_STATUS_PUBLISHING},
+			dbx.HashExp{"id": scheduledPost.Id, "status": teleblog.SCHEDULED_POST_STATUS_SCHEDULED},
+		).Execute()
+		if err != nil {
+			return fmt.Errorf("PublishScheduledPosts: claim scheduled post error: %w", err)
+		}
+
+		// # Canceled or rescheduled meanwhile
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+
+		tgPostId, err := sendScheduledPost(app, b, scheduledPost, chat)
+		if err != nil {
+			app.Logger().Error("Error while publishing scheduled post", "error", err, "scheduled_post_id", scheduledPost.Id)
+
+			scheduledPost.Status = teleblog.SCHEDULED_POST_STATUS_FAILED
+			scheduledPost.Error = err.Error()
+
+			NotifyChatOwner(
+				app,
+				chat.Id,
+				teleblog.NOTIFICATION_POST_FAILED,
+				fmt.Sprintf("Failed to publish scheduled post %s to %s: %s", scheduledPost.Id, chat.TgTitle, err),
+			)
+		} else {
+			scheduledPost.Status = teleblog.SCHEDULED_POST_STATUS_PUBLISHED
+			scheduledPost.Error = ""
+			scheduledPost.TgPostId = tgPostId
+		}
+
+		if err := app.Dao().Save(scheduledPost); err != nil {
+			return fmt.Errorf("PublishScheduledPosts: save scheduled post error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DEFAULT_SCHEDULER_INTERVAL is used when configured interval is not positive
+const DEFAULT_SCHEDULER_INTERVAL = 30 * time.Second
+
+// InitScheduledPosts publishes scheduled posts every interval
+func InitScheduledPosts(app *pocketbase.PocketBase, b *telebot.Bot, interval time.Duration) {
+	if interval <= 0 {
+		app.Logger().Warn("Wrong SCHEDULER_INTERVAL, default is used", "interval", interval, "default", DEFAULT_SCHEDULER_INTERVAL)
+		interval = DEFAULT_SCHEDULER_INTERVAL
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := PublishScheduledPosts(app, b); err != nil {
+				app.Logger().Error("Error while publishing scheduled posts", "error", err)
+			}
+		}
+	}()
+}
@@ -31,11 +31,7 @@ func SetAlbumId(app *pocketbase.PocketBase) error {
 			err = json.Unmarshal(jb, &rawMessage)
 			if err != nil {
 				app.Logger().Error("SetAlbumId: unmarshal history message error", "error", err, "post_id", post.Id)
-				_, err := app.DB().Update(
-					"post",
-					dbx.Params{"unparsable": true},
-					dbx.HashExp{"id": post.Id},
-				).Execute()
+				err = MarkPostUnparsable(app, post, err)
 				if err != nil {
 					return fmt.Errorf("IndexPageHandler: update post error: %w", err)
 				}
@@ -50,11 +46,7 @@ func SetAlbumId(app *pocketbase.PocketBase) error {
 			err = json.Unmarshal(jb, &rawMessage)
 			if err != nil {
 				app.Logger().Error("SetAlbumId: unmarshal realtime message error", "error", err, "post_id", post.Id)
-				_, err := app.DB().Update(
-					"post",
-					dbx.Params{"unparsable": true},
-					dbx.HashExp{"id": post.Id},
-				).Execute()
+				err = MarkPostUnparsable(app, post, err)
 				if err != nil {
 					return fmt.Errorf("IndexPageHandler: update post error: %w", err)
 				}
@@ -0,0 +1,208 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// # Trained classifier is cached until labeled comments change
+var spamClassifierCache = struct {
+	sync.Mutex
+	classifier *teleblog.SpamClassifier
+}{}
+
+// # Ids of comments which spam label is changed by the current update
+var spamLabelUpdates = struct {
+	sync.Mutex
+	ids map[string]bool
+}{
+	ids: map[string]bool{},
+}
+
+// commentSpamLabel returns spam label of the comment saved as model
+// or as record, false is returned for other models
+func commentSpamLabel(model models.Model) (string, bool) {
+	switch m := model.(type) {
+	case *teleblog.Comment:
+		return m.SpamLabel, true
+	case *models.Record:
+		if m.Collection().Name == (&teleblog.Comment{}).TableName() {
+			return m.GetString("spam_label"), true
+		}
+	}
+
+	return "", false
+}
+
+func invalidateSpamClassifier() {
+	spamClassifierCache.Lock()
+	defer spamClassifierCache.Unlock()
+
+	spamClassifierCache.classifier = nil
+}
+
+func getSpamClassifier(dao *daos.Dao) (*teleblog.SpamClassifier, error) {
+	spamClassifierCache.Lock()
+	defer spamClassifierCache.Unlock()
+
+	if spamClassifierCache.classifier != nil {
+		return spamClassifierCache.classifier, nil
+	}
+
+	classifier, err := teleblog.TrainSpamClassifier(dao)
+	if err != nil {
+		return nil, err
+	}
+
+	spamClassifierCache.classifier = classifier
+
+	return classifier, nil
+}
+
+func classifyComment(dao *daos.Dao, classifier *teleblog.SpamClassifier, comment *teleblog.Comment, threshold float64) error {
+	score := classifier.Score(teleblog.CommentSpamTokens(comment))
+
+	// # Owner label always wins
+	spam := comment.SpamLabel == teleblog.SPAM_LABEL ||
+		(comment.SpamLabel == "" && classifier.IsTrained() && score >= threshold)
+
+	if spam == comment.Spam && score == comment.SpamScore {
+		return nil
+	}
+
+	_, err := dao.DB().Update(
+		comment.TableName(),
+		dbx.Params{"spam": spam, "spam_score": score},
+		dbx.HashExp{"id": comment.Id},
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("classifyComment: update comment error: %w", err)
+	}
+
+	return nil
+}
+
+// ClassifyComment scores the comment and hides it if it is spam
+func ClassifyComment(dao *daos.Dao, commentId string, threshold float64) error {
+	comment := &teleblog.Comment{}
+
+	err := teleblog.CommentQuery(dao).
+		Where(dbx.HashExp{"id": commentId}).
+		Limit(1).
+		One(comment)
+	if err != nil {
+		return fmt.Errorf("ClassifyComment: get comment error: %w", err)
+	}
+
+	classifier, err := getSpamClassifier(dao)
+	if err != nil {
+		return err
+	}
+
+	return classifyComment(dao, classifier, comment, threshold)
+}
+
+// RescoreAllComments retrains classifier and scores all comments again
+func RescoreAllComments(app *pocketbase.PocketBase, threshold float64) error {
+	invalidateSpamClassifier()
+
+	classifier, err := getSpamClassifier(app.Dao())
+	if err != nil {
+		return err
+	}
+
+	comments := []*teleblog.Comment{}
+
+	err = teleblog.CommentQuery(app.Dao()).All(&comments)
+	if err != nil {
+		return fmt.Errorf("RescoreAllComments: get comments error: %w", err)
+	}
+
+	for _, comment := range comments {
+		err := classifyComment(app.Dao(), classifier, comment, threshold)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InitSpamClassifier scores every new or changed comment and
+// retrains classifier when owners label comments
+func InitSpamClassifier(app *pocketbase.PocketBase, threshold float64) {
+	commentTableName := (&teleblog.Comment{}).TableName()
+
+	classify := func(e *core.ModelEvent) error {
+		err := ClassifyComment(e.Dao, e.Model.GetId(), threshold)
+		if err != nil {
+			// # Don't break comments saving because of classifier
+			app.Logger().Error("Error while classifying comment", "error", err, "comment_id", e.Model.GetId())
+		}
+
+		return nil
+	}
+
+	app.OnModelAfterCreate(commentTableName).Add(func(e *core.ModelEvent) error {
+		if label, _ := commentSpamLabel(e.Model); label != "" {
+			invalidateSpamClassifier()
+		}
+
+		return classify(e)
+	})
+
+	app.OnModelBeforeUpdate(commentTableName).Add(func(e *core.ModelEvent) error {
+		label, ok := commentSpamLabel(e.Model)
+		if !ok {
+			return nil
+		}
+
+		oldLabel := ""
+
+		err := teleblog.CommentQuery(e.Dao).
+			Select("spam_label").
+			Where(dbx.HashExp{"id": e.Model.GetId()}).
+			Row(&oldLabel)
+		if err != nil && !strings.Contains(err.Error(), "no rows") {
+			return fmt.Errorf("InitSpamClassifier: get old label error: %w", err)
+		}
+
+		if label == oldLabel {
+			return nil
+		}
+
+		spamLabelUpdates.Lock()
+		spamLabelUpdates.ids[e.Model.GetId()] = true
+		spamLabelUpdates.Unlock()
+
+		return nil
+	})
+
+	app.OnModelAfterUpdate(commentTableName).Add(func(e *core.ModelEvent) error {
+		spamLabelUpdates.Lock()
+		labelChanged := spamLabelUpdates.ids[e.Model.GetId()]
+		delete(spamLabelUpdates.ids, e.Model.GetId())
+		spamLabelUpdates.Unlock()
+
+		// # Classifier is trained on labeled comments only
+		if labelChanged {
+			invalidateSpamClassifier()
+		}
+
+		return classify(e)
+	})
+
+	app.OnModelAfterDelete(commentTableName).Add(func(e *core.ModelEvent) error {
+		invalidateSpamClassifier()
+
+		return nil
+	})
+}
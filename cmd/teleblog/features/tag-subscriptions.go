@@ -0,0 +1,203 @@
+package features
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"gopkg.in/telebot.v4"
+)
+
+// # Telegram allows about 30 messages per second for the bot
+const tagSubscriptionSendInterval = 50 * time.Millisecond
+
+// # Older posts (e.g. edited ones) are not sent to subscribers
+const tagSubscriptionMaxPostAge = 24 * time.Hour
+
+type tagPostMessage struct {
+	tgUserId int64
+	text     string
+}
+
+// # Fan-out queue of new posts for tag subscribers
+var tagSubscriptionsSender = struct {
+	sync.Mutex
+	queue chan tagPostMessage
+}{}
+
+func enqueueTagPost(app core.App, dao *daos.Dao, postTagId string) error {
+	postTag := &teleblog.PostTag{}
+
+	err := dao.ModelQuery(postTag).
+		Where(dbx.HashExp{"id": postTagId}).
+		Limit(1).
+		One(postTag)
+	if err != nil {
+		return err
+	}
+
+	post := &teleblog.Post{}
+
+	err = teleblog.PostQuery(dao).
+		Where(dbx.HashExp{"id": postTag.PostId}).
+		Limit(1).
+		One(post)
+	if err != nil {
+		return err
+	}
+
+	if post.IsTgHistoryMessage || post.Unparsable || post.Hidden || time.Since(post.Created.Time()) > tagSubscriptionMaxPostAge {
+		return nil
+	}
+
+	subscriptions := []*teleblog.TagSubscription{}
+
+	err = teleblog.TagSubscriptionQuery(dao).
+		Where(dbx.HashExp{"tag_id": postTag.TagId}).
+		All(&subscriptions)
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	tag := &teleblog.Tag{}
+
+	err = teleblog.TagQuery(dao).
+		Where(dbx.HashExp{"id": postTag.TagId}).
+		Limit(1).
+		One(tag)
+	if err != nil {
+		return err
+	}
+
+	chat := &teleblog.Chat{}
+
+	err = teleblog.ChatQuery(dao).
+		Where(dbx.HashExp{"id": post.ChatId}).
+		Limit(1).
+		One(chat)
+	if err != nil {
+		return err
+	}
+
+	siteConfig, err := teleblog.FindChatTenantConfig(dao, chat)
+	if err != nil {
+		return err
+	}
+
+	siteUrl := siteConfig.SiteUrl(app.Settings().Meta.AppUrl)
+
+	permalinks, err := teleblog.NewPermalinks(dao, siteConfig)
+	if err != nil {
+		return err
+	}
+
+	title := post.Title
+	if title == "" {
+		title = chat.TgTitle
+	}
+
+	text := fmt.Sprintf("New post #%s: %s\n\n%s%s", tag.Value, title, siteUrl, permalinks.PostPath(*post))
+
+	tagSubscriptionsSender.Lock()
+	defer tagSubscriptionsSender.Unlock()
+
+	// # Post with many tags is sent once, also after restart
+	notifiedTgUserIds := types.JsonArray[int64]{}
+
+	err = dao.DB().
+		Select("tag_notified_tg_user_ids").
+		From(post.TableName()).
+		Where(dbx.HashExp{"id": post.Id}).
+		Row(&notifiedTgUserIds)
+	if err != nil {
+		return err
+	}
+
+	queued := false
+
+	for _, subscription := range subscriptions {
+		if slices.Contains(notifiedTgUserIds, subscription.TgUserId) {
+			continue
+		}
+
+		select {
+		case tagSubscriptionsSender.queue <- tagPostMessage{tgUserId: subscription.TgUserId, text: text}:
+			notifiedTgUserIds = append(notifiedTgUserIds, subscription.TgUserId)
+			queued = true
+		default:
+			app.Logger().Warn("Tag subscriptions queue is full", "post_id", post.Id, "tg_user_id", subscription.TgUserId)
+		}
+	}
+
+	if !queued {
+		return nil
+	}
+
+	// # Not through dao, because post update hooks are not needed
+	_, err = dao.DB().Update(
+		post.TableName(),
+		dbx.Params{"tag_notified_tg_user_ids": notifiedTgUserIds},
+		dbx.HashExp{"id": post.Id},
+	).Execute()
+
+	return err
+}
+
+// sendTagPosts sends queued messages not faster than Telegram allows
+func sendTagPosts(app *pocketbase.PocketBase, b *telebot.Bot) {
+	ticker := time.NewTicker(tagSubscriptionSendInterval)
+	defer ticker.Stop()
+
+	for message := range tagSubscriptionsSender.queue {
+		<-ticker.C
+
+		_, err := b.Send(&telebot.User{ID: message.tgUserId}, message.text)
+		if err == nil {
+			continue
+		}
+
+		app.Logger().Error("Error while sending tag post", "error", err, "tg_user_id", message.tgUserId)
+
+		// # Reader blocked the bot, so stop sending
+		if strings.Contains(err.Error(), "blocked") || strings.Contains(err.Error(), "deactivated") {
+			_, err := app.DB().Delete(
+				(&teleblog.TagSubscription{}).TableName(),
+				dbx.HashExp{"tg_user_id": message.tgUserId},
+			).Execute()
+			if err != nil {
+				app.Logger().Error("Error while removing subscriptions", "error", err, "tg_user_id", message.tgUserId)
+			}
+		}
+	}
+}
+
+// InitTagSubscriptions sends new posts to readers subscribed to their tags
+func InitTagSubscriptions(app *pocketbase.PocketBase, b *telebot.Bot) {
+	tagSubscriptionsSender.Lock()
+	tagSubscriptionsSender.queue = make(chan tagPostMessage, 10000)
+	tagSubscriptionsSender.Unlock()
+
+	app.OnModelAfterCreate((&teleblog.PostTag{}).TableName()).Add(func(e *core.ModelEvent) error {
+		err := enqueueTagPost(app, e.Dao, e.Model.GetId())
+		if err != nil {
+			// # Don't break post saving because of subscriptions
+			app.Logger().Error("Error while sending post to tag subscribers", "error", err, "post_tag_id", e.Model.GetId())
+		}
+
+		return nil
+	})
+
+	go sendTagPosts(app, b)
+}
@@ -0,0 +1,59 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// MarkPostUnparsable hides post which raw message can't be parsed
+// and tells the owner about it
+func MarkPostUnparsable(app core.App, post *teleblog.Post, reason error) error {
+	post.Unparsable = true
+	post.UnparsableReason = reason.Error()
+
+	_, err := app.DB().Update(
+		post.TableName(),
+		dbx.Params{"unparsable": true, "unparsable_reason": post.UnparsableReason},
+		dbx.HashExp{"id": post.Id},
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("MarkPostUnparsable: update post error: %w", err)
+	}
+
+	NotifyChatOwner(
+		app,
+		post.ChatId,
+		teleblog.NOTIFICATION_UNPARSABLE_POST,
+		fmt.Sprintf("Post %d can't be parsed and is hidden from the blog: %s", post.TgMessageId, reason),
+	)
+
+	return nil
+}
+
+// UnparsablePostsGroup is unparsable posts failed with the same error
+type UnparsablePostsGroup struct {
+	Reason string `json:"reason" db:"unparsable_reason"`
+	Count  int    `json:"count" db:"count"`
+}
+
+// UnparsablePostsReport groups unparsable posts by their errors,
+// posts marked before reasons were stored have empty reason
+func UnparsablePostsReport(app core.App) ([]UnparsablePostsGroup, error) {
+	groups := []UnparsablePostsGroup{}
+
+	err := app.Dao().DB().
+		Select("unparsable_reason", "count(*) as count").
+		From((&teleblog.Post{}).TableName()).
+		Where(dbx.HashExp{"unparsable": true}).
+		GroupBy("unparsable_reason").
+		OrderBy("count desc").
+		All(&groups)
+	if err != nil {
+		return nil, fmt.Errorf("UnparsablePostsReport: get posts error: %w", err)
+	}
+
+	return groups, nil
+}
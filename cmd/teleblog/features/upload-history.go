@@ -29,12 +29,43 @@ func ParseChannelHistory(app core.App, historyZip teleblog.HistoryExport, histor
 		return err
 	}
 
+	// # Ids of the export range which are not posts (service or deleted messages)
+	skippedIds := []int{}
+	exportedIds := map[int]bool{}
+	minId, maxId := 0, 0
+
+	for _, message := range history.Messages {
+		exportedIds[message.Id] = true
+
+		if message.Type != "message" {
+			skippedIds = append(skippedIds, message.Id)
+		}
+
+		if minId == 0 || message.Id < minId {
+			minId = message.Id
+		}
+
+		if message.Id > maxId {
+			maxId = message.Id
+		}
+	}
+
+	for id := minId; id <= maxId; id++ {
+		if !exportedIds[id] {
+			skippedIds = append(skippedIds, id)
+		}
+	}
+
+	if err := SkipPostIds(app, chat, skippedIds...); err != nil {
+		return err
+	}
+
 	for _, message := range history.Messages {
 		if message.Type != "message" {
 			continue
 		}
 
-		// # Skip if exists
+		// # Skip if exists, so only missing posts are imported
 		total := struct {
 			Total int64 `db:"total"`
 		}{}
@@ -69,9 +100,9 @@ func ParseChannelHistory(app core.App, historyZip teleblog.HistoryExport, histor
 			IsTgHistoryMessage: true,
 			Text:               text,
 			TgMessageId:        message.Id,
+			TgReplyToPostId:    message.ReplyToMessageId,
 			Title:              templu.RemoveNewLines(fmt.Sprintf("%.60s", text)),
 			SeoDescription:     templu.RemoveNewLines(fmt.Sprintf("%.160s", text)),
-			Slug:               slug.GenerateSlug(text, time.Now()),
 		}
 
 		// # post.Created
@@ -84,6 +115,9 @@ func ParseChannelHistory(app core.App, historyZip teleblog.HistoryExport, histor
 			post.Created.Scan(tm)
 		}
 
+		// # Slug is dated by the post, not by the import
+		post.Slug = slug.GenerateSlug(text, post.Created.Time())
+
 		// # post.TgMessageRaw
 		jsonMessageRaw, err := json.Marshal(message)
 		if err != nil {
@@ -276,18 +310,22 @@ func ParseGroupHistory(app core.App, history teleblog.History, chat *teleblog.Ch
 
 				// # If none, than find it in DB
 				if parentComment == nil {
+					dbComment := &teleblog.Comment{}
+
 					err := teleblog.CommentQuery(app.Dao()).
 						Where(
 							dbx.HashExp{"tg_comment_id": message.ReplyToMessageId, "chat_id": chat.Id},
 						).
 						Limit(1).
-						One(parentComment)
-					if err != nil {
-						if strings.Contains(err.Error(), "no rows in result set") {
-							continue
-						}
+						One(dbComment)
+					if err != nil && !strings.Contains(err.Error(), "no rows in result set") {
 						return err
 					}
+
+					// # Comment is saved without post, it is linked later by RelinkComments
+					if err == nil {
+						parentComment = dbComment
+					}
 				}
 
 				if parentComment != nil && parentComment.PostId != "" {
@@ -387,7 +425,9 @@ func UploadHistory(app *pocketbase.PocketBase, historyExportPath string) error {
 		return fmt.Errorf("failed to find chat with tg_chat_id %d: %v", chatId, err)
 	}
 
-	if chat.TgType == "channel" {
+	missingGapsMessage := ""
+
+	if chat.TgType == "channel" || chat.TgType == "privatechannel" {
 		err := ParseChannelHistory(app, *structure, history, &chat)
 		if err != nil {
 			return err
@@ -398,10 +438,67 @@ func UploadHistory(app *pocketbase.PocketBase, historyExportPath string) error {
 			return err
 		}
 
-		return nil
+		knownGaps := chat.TgPostGaps
+
+		gaps, err := DetectPostGaps(app, &chat)
+		if err != nil {
+			return err
+		}
+
+		// # New gaps are reported by DetectPostGaps, if it is sent they become known
+		newGaps := teleblog.NewPostGaps(knownGaps, gaps)
+		newGapsReported := len(newGaps) > 0 && len(teleblog.NewPostGaps(chat.TgPostGaps, newGaps)) == 0
+
+		if len(gaps) > 0 && !newGapsReported {
+			missingGapsMessage = fmt.Sprintf(" Posts %s are still missing, upload history export covering them.", teleblog.FormatPostGaps(gaps))
+		}
 	} else if chat.TgType == "supergroup" || chat.TgType == "group" || chat.TgType == "private_supergroup" {
-		return ParseGroupHistory(app, history, &chat)
+		err := ParseGroupHistory(app, history, &chat)
+		if err != nil {
+			return err
+		}
+	} else {
+		return nil
 	}
 
+	// # Imported posts and comments can complete threads of the discussion group
+	groupChat := &chat
+
+	if chat.TgType == "channel" || chat.TgType == "privatechannel" {
+		groupChat = nil
+
+		if chat.LinkedChatId != "" {
+			groupChat = &teleblog.Chat{}
+
+			err := teleblog.ChatQuery(app.Dao()).
+				Where(dbx.HashExp{"id": chat.LinkedChatId}).
+				Limit(1).
+				One(groupChat)
+			if err != nil {
+				return fmt.Errorf("failed to find linked chat: %w", err)
+			}
+		}
+	}
+
+	message := fmt.Sprintf("History import of %s is completed (%d messages).%s", history.Name, len(history.Messages), missingGapsMessage)
+
+	if groupChat != nil {
+		relinkResult, err := RelinkComments(app, groupChat)
+		if err != nil {
+			return err
+		}
+
+		if len(relinkResult.Unresolved) > 0 {
+			message += fmt.Sprintf(" %d comments are not linked to posts, upload history of the channel covering them and run relink-comments.", len(relinkResult.Unresolved))
+		}
+	}
+
+	NotifyChatOwner(
+		app,
+		chat.Id,
+		teleblog.NOTIFICATION_HISTORY_IMPORTED,
+		message,
+	)
+
 	return nil
 }
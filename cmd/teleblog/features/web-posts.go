@@ -0,0 +1,137 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/Dionid/teleblog/libs/slug"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// isWebPostRecord checks if post record was created in the admin panel
+func isWebPostRecord(model models.Model) (*models.Record, bool) {
+	record, ok := model.(*models.Record)
+	if !ok || record.Collection().Name != (&teleblog.Post{}).TableName() {
+		return nil, false
+	}
+
+	return record, !record.GetBool("is_tg_message") && !record.GetBool("is_tg_history_message")
+}
+
+// prepareWebPost fills fields Telegram posts get from the message
+func prepareWebPost(record *models.Record) error {
+	// # Posts are shown on the sites of their channels
+	if record.GetString("chat_id") == "" {
+		return fmt.Errorf("Choose channel of the post")
+	}
+
+	text, err := teleblog.MarkdownToText(record.GetString("markdown"))
+	if err != nil {
+		return fmt.Errorf("prepareWebPost: render markdown error: %w", err)
+	}
+
+	record.Set("text", text)
+	record.Set("unparsable", false)
+
+	if record.GetString("title") == "" {
+		record.Set("title", templu.RemoveNewLines(fmt.Sprintf("%.60s", text)))
+	}
+
+	if record.GetString("seo_description") == "" {
+		record.Set("seo_description", templu.RemoveNewLines(fmt.Sprintf("%.160s", text)))
+	}
+
+	if record.GetString("slug") == "" {
+		record.Set("slug", slug.GenerateSlug(record.GetString("title"), record.Created.Time()))
+	}
+
+	// # Posts are grouped by album, so web post is an album of its own
+	if record.GetString("album_id") == "" {
+		record.Set("album_id", record.Id)
+	}
+
+	return nil
+}
+
+// saveWebPostTags saves tags of the web post and removes deleted ones
+func saveWebPostTags(app *pocketbase.PocketBase, postId string) error {
+	post := teleblog.Post{}
+
+	err := teleblog.PostQuery(app.Dao()).
+		Where(dbx.HashExp{"id": postId}).
+		Limit(1).
+		One(&post)
+	if err != nil {
+		return err
+	}
+
+	err = ExtractAndSavePostTags(app, post)
+	if err != nil {
+		return err
+	}
+
+	values := []any{}
+	for _, value := range teleblog.ParseWebPostTags(post.Tags) {
+		values = append(values, value)
+	}
+
+	tagIds := []string{}
+
+	err = teleblog.TagQuery(app.Dao()).
+		Select("tag.id").
+		Where(dbx.In("value", values...)).
+		Column(&tagIds)
+	if err != nil {
+		return err
+	}
+
+	keep := []any{}
+	for _, tagId := range tagIds {
+		keep = append(keep, tagId)
+	}
+
+	_, err = app.DB().Delete(
+		(&teleblog.PostTag{}).TableName(),
+		dbx.And(
+			dbx.HashExp{"post_id": post.Id},
+			dbx.NotIn("tag_id", keep...),
+		),
+	).Execute()
+
+	return err
+}
+
+// InitWebPosts lets admins write posts in the admin panel with markdown
+func InitWebPosts(app *pocketbase.PocketBase) {
+	beforeSave := func(e *core.ModelEvent) error {
+		record, ok := isWebPostRecord(e.Model)
+		if !ok {
+			return nil
+		}
+
+		return prepareWebPost(record)
+	}
+
+	app.OnModelBeforeCreate((&teleblog.Post{}).TableName()).Add(beforeSave)
+	app.OnModelBeforeUpdate((&teleblog.Post{}).TableName()).Add(beforeSave)
+
+	afterSave := func(e *core.ModelEvent) error {
+		if _, ok := isWebPostRecord(e.Model); !ok {
+			return nil
+		}
+
+		err := saveWebPostTags(app, e.Model.GetId())
+		if err != nil {
+			return fmt.Errorf("InitWebPosts: save tags error: %w", err)
+		}
+
+		return nil
+	}
+
+	app.OnModelAfterCreate((&teleblog.Post{}).TableName()).Add(afterSave)
+	app.OnModelAfterUpdate((&teleblog.Post{}).TableName()).Add(afterSave)
+}
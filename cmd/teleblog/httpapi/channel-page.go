@@ -0,0 +1,12 @@
+package httpapi
+
+import (
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func ChannelPageHandler(e *core.ServeEvent, app core.App) {
+	e.Router.GET("/c/:username", func(c echo.Context) error {
+		return renderPostsListPage(c, app, c.PathParam("username"))
+	})
+}
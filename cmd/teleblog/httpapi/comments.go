@@ -0,0 +1,291 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"gopkg.in/telebot.v4"
+)
+
+const (
+	// Top level comments (threads) per page
+	commentThreadsPerPage = 30
+	// Replies deeper than this are shown on this level
+	maxCommentsDepth = 30
+)
+
+type PostCommentsFilters struct {
+	Page int64 `query:"page"`
+}
+
+// prepareComment fills author, text markup and media urls of the comment
+func prepareComment(comment *views.CommentWithTextWithMarkup, commentCollection *models.Collection) error {
+	for i, media := range comment.Media {
+		comment.Media[i] = "/api/files/" + commentCollection.Id + "/" + comment.Id + "/" + media
+	}
+
+	jb, err := comment.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("PostPageHandler: marshal comment error: %w", err)
+	}
+
+	if comment.IsTgHistoryMessage {
+		rawMessage := teleblog.HistoryMessage{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err != nil {
+			return fmt.Errorf("PostPageHandler: unmarshal history message error: %w", err)
+		}
+
+		comment.AuthorTitle = rawMessage.From
+
+		if len(rawMessage.Text.Items) > 0 {
+			comment.TextWithMarkup = teleblog.FormHistoryRawTextWithMarkup(rawMessage.Text)
+		} else {
+			comment.TextWithMarkup = teleblog.HistoryTextEntitiesWithToTextWithMarkup(rawMessage.TextEntities)
+		}
+	} else {
+		rawMessage := telebot.Message{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err != nil {
+			return err
+		}
+
+		if rawMessage.Sender.IsBot && rawMessage.SenderChat != nil {
+			comment.AuthorTitle = rawMessage.SenderChat.Title
+			comment.AuthorUsername = &rawMessage.SenderChat.Username
+		} else {
+			comment.AuthorTitle = rawMessage.Sender.FirstName + " " + rawMessage.Sender.LastName
+			comment.AuthorUsername = &rawMessage.Sender.Username
+		}
+
+		if len(rawMessage.Entities) > 0 {
+			comment.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Text, rawMessage.Entities)
+			if err != nil {
+				return err
+			}
+		} else if len(rawMessage.CaptionEntities) > 0 {
+			comment.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Caption, rawMessage.CaptionEntities)
+			if err != nil {
+				return err
+			}
+		} else {
+			comment.TextWithMarkup = strings.ReplaceAll(
+				html.EscapeString(rawMessage.Text+rawMessage.Caption),
+				"\n",
+				"<br>",
+			)
+		}
+	}
+
+	return nil
+}
+
+// postCommentsQuery selects comments of the post (and its album posts)
+func postCommentsQuery(app core.App, postsIds []any) *dbx.SelectQuery {
+	return teleblog.CommentQuery(app.Dao()).
+		Where(
+			dbx.In("comment.post_id", postsIds...),
+		).
+		AndWhere(teleblog.VisibleCommentExp())
+}
+
+// threadRootExp matches comments which parent is not a visible comment of the same post
+var threadRootExp = dbx.NewExp(`NOT EXISTS (
+	SELECT 1 FROM comment AS parent
+	WHERE parent.chat_id = comment.chat_id
+		AND parent.post_id = comment.post_id
+		AND parent.tg_comment_id = comment.tg_reply_to_message_id
+		AND parent.hidden = false
+		AND parent.filtered = false
+		AND parent.spam = false
+)`)
+
+// attachCommentReplies adds replies of the depth to comments they reply to.
+// Replies deeper than maxDepth are added next to their parents, so the
+// tree is never deeper and all counted comments are shown. holders maps Telegram
+// id of the comment to the comment its replies are added to. Attached
+// replies are returned.
+func attachCommentReplies(
+	holders map[int]*views.PostPageComment,
+	replies []*views.PostPageComment,
+	depth int,
+	maxDepth int,
+) []*views.PostPageComment {
+	attached := []*views.PostPageComment{}
+
+	for _, reply := range replies {
+		holder, ok := holders[reply.TgReplyToMessageId]
+		if !ok {
+			continue
+		}
+
+		// # Already attached (e.g. same ids in album posts)
+		if _, ok := holders[reply.TgMessageId]; ok {
+			continue
+		}
+
+		if depth >= maxDepth {
+			// # Replies to the deepest comments are added next to them
+			holders[reply.TgMessageId] = holder
+		} else {
+			holders[reply.TgMessageId] = reply
+		}
+
+		holder.Replies = append(holder.Replies, reply)
+		attached = append(attached, reply)
+	}
+
+	return attached
+}
+
+// LoadCommentThreads loads page of top level comments with all their replies
+// and returns them as a tree along with pagination and total comments count
+func LoadCommentThreads(
+	app core.App,
+	post views.PostPagePost,
+	postsIds []any,
+	filters PostCommentsFilters,
+) ([]*views.PostPageComment, views.PaginationData, int64, error) {
+	pagination := views.PaginationData{
+		PerPage:     commentThreadsPerPage,
+		CurrentPage: filters.Page,
+	}
+
+	if pagination.CurrentPage < 1 {
+		pagination.CurrentPage = 1
+	}
+
+	commentCollection, err := app.Dao().FindCollectionByNameOrId("comment")
+	if err != nil {
+		return nil, pagination, 0, err
+	}
+
+	// # Totals
+	total := struct {
+		Total int64 `db:"total"`
+	}{}
+
+	err = postCommentsQuery(app, postsIds).
+		Select("count(*) as total").
+		One(&total)
+	if err != nil {
+		return nil, pagination, 0, fmt.Errorf("LoadCommentThreads: count comments error: %w", err)
+	}
+
+	rootsTotal := struct {
+		Total int64 `db:"total"`
+	}{}
+
+	err = postCommentsQuery(app, postsIds).
+		AndWhere(threadRootExp).
+		Select("count(*) as total").
+		One(&rootsTotal)
+	if err != nil {
+		return nil, pagination, 0, fmt.Errorf("LoadCommentThreads: count threads error: %w", err)
+	}
+
+	pagination.Total = rootsTotal.Total
+
+	// # Thread roots
+	roots := []*views.PostPageComment{}
+
+	err = postCommentsQuery(app, postsIds).
+		AndWhere(threadRootExp).
+		OrderBy("comment.created asc", "comment.tg_comment_id asc").
+		Limit(pagination.PerPage).
+		Offset((pagination.CurrentPage - 1) * pagination.PerPage).
+		All(&roots)
+	if err != nil {
+		return nil, pagination, 0, fmt.Errorf("LoadCommentThreads: get threads error: %w", err)
+	}
+
+	// # Replies, level by level
+	holders := map[int]*views.PostPageComment{}
+	level := roots
+
+	for _, root := range roots {
+		holders[root.TgMessageId] = root
+	}
+
+	for depth := 1; len(level) > 0; depth++ {
+		parentIds := []any{}
+
+		for _, comment := range level {
+			err := prepareComment(&comment.CommentWithTextWithMarkup, commentCollection)
+			if err != nil {
+				return nil, pagination, 0, err
+			}
+
+			parentIds = append(parentIds, comment.TgMessageId)
+		}
+
+		replies := []*views.PostPageComment{}
+
+		err := postCommentsQuery(app, postsIds).
+			AndWhere(dbx.In("comment.tg_reply_to_message_id", parentIds...)).
+			OrderBy("comment.created asc", "comment.tg_comment_id asc").
+			All(&replies)
+		if err != nil {
+			return nil, pagination, 0, fmt.Errorf("LoadCommentThreads: get replies error: %w", err)
+		}
+
+		level = attachCommentReplies(holders, replies, depth, maxCommentsDepth)
+	}
+
+	// # Link roots that reply to comments outside of this post
+	missingParentIds := []any{}
+
+	for _, root := range roots {
+		if root.TgReplyToMessageId <= 0 ||
+			root.TgReplyToMessageId == post.TgGroupMessageId ||
+			root.TgReplyToMessageId == post.TgMessageId {
+			continue
+		}
+
+		root.UnloadedReplyToId = root.TgReplyToMessageId
+		missingParentIds = append(missingParentIds, root.TgReplyToMessageId)
+	}
+
+	if len(missingParentIds) > 0 {
+		parents := []*views.CommentWithTextWithMarkup{}
+
+		err := teleblog.CommentQuery(app.Dao()).
+			Where(dbx.In("comment.tg_comment_id", missingParentIds...)).
+			AndWhere(teleblog.VisibleCommentExp()).
+			All(&parents)
+		if err != nil {
+			return nil, pagination, 0, fmt.Errorf("LoadCommentThreads: get replied comments error: %w", err)
+		}
+
+		for _, root := range roots {
+			if root.UnloadedReplyToId == 0 {
+				continue
+			}
+
+			for _, parent := range parents {
+				if parent.ChatId != root.ChatId || parent.TgMessageId != root.UnloadedReplyToId {
+					continue
+				}
+
+				err := prepareComment(parent, commentCollection)
+				if err != nil {
+					return nil, pagination, 0, err
+				}
+
+				root.ReplyToComment = parent
+				break
+			}
+		}
+	}
+
+	return roots, pagination, total.Total, nil
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"testing"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
+)
+
+func newTestComment(tgMessageId int, tgReplyToMessageId int) *views.PostPageComment {
+	comment := &views.PostPageComment{}
+	comment.TgMessageId = tgMessageId
+	comment.TgReplyToMessageId = tgReplyToMessageId
+
+	return comment
+}
+
+// buildTestCommentTree attaches comments level by level, as post page does
+func buildTestCommentTree(root *views.PostPageComment, levels [][]*views.PostPageComment, maxDepth int) int {
+	holders := map[int]*views.PostPageComment{root.TgMessageId: root}
+	attached := 0
+
+	for i, replies := range levels {
+		attached += len(attachCommentReplies(holders, replies, i+1, maxDepth))
+	}
+
+	return attached
+}
+
+// commentTreeDepth returns number of reply levels under the comment
+func commentTreeDepth(comment *views.PostPageComment) int {
+	depth := 0
+
+	for _, reply := range comment.Replies {
+		if replyDepth := commentTreeDepth(reply) + 1; replyDepth > depth {
+			depth = replyDepth
+		}
+	}
+
+	return depth
+}
+
+func TestAttachCommentReplies(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    int
+		maxDepth int
+		depth    int
+		// Replies of the deepest shown comment
+		deepestReplies int
+	}{
+		{"shallow chain", 3, 5, 3, 1},
+		{"chain of max depth", 5, 5, 5, 1},
+		{"deeper chain", 8, 5, 5, 4},
+		{"max depth 1", 4, 1, 1, 4},
+	}
+
+	for _, test := range tests {
+		root := newTestComment(1, 0)
+		levels := [][]*views.PostPageComment{}
+
+		// # Each comment replies to the previous one
+		for i := 0; i < test.chain; i++ {
+			levels = append(levels, []*views.PostPageComment{newTestComment(i+2, i+1)})
+		}
+
+		attached := buildTestCommentTree(root, levels, test.maxDepth)
+
+		if attached != test.chain {
+			t.Errorf("%s: attached %d replies, want %d", test.name, attached, test.chain)
+		}
+
+		if depth := commentTreeDepth(root); depth != test.depth {
+			t.Errorf("%s: tree depth %d, want %d", test.name, depth, test.depth)
+		}
+
+		deepest := root
+		for len(deepest.Replies) == 1 && len(deepest.Replies[0].Replies) > 0 {
+			deepest = deepest.Replies[0]
+		}
+
+		if len(deepest.Replies) != test.deepestReplies {
+			t.Errorf("%s: deepest comment has %d replies, want %d", test.name, len(deepest.Replies), test.deepestReplies)
+		}
+	}
+}
+
+func TestAttachCommentRepliesSkipsUnknownAndAttached(t *testing.T) {
+	root := newTestComment(1, 0)
+
+	attached := buildTestCommentTree(root, [][]*views.PostPageComment{
+		{
+			newTestComment(2, 1),
+			// # Reply to comment which is not loaded
+			newTestComment(3, 100),
+			// # Same comment in other album post
+			newTestComment(2, 1),
+		},
+		{
+			newTestComment(4, 2),
+			newTestComment(5, 1),
+		},
+	}, 5)
+
+	if attached != 3 {
+		t.Errorf("attached %d replies, want 3", attached)
+	}
+
+	if len(root.Replies) != 2 || root.Replies[0].TgMessageId != 2 || root.Replies[1].TgMessageId != 5 {
+		t.Errorf("root replies are wrong: %d", len(root.Replies))
+	}
+
+	if len(root.Replies[0].Replies) != 1 || root.Replies[0].Replies[0].TgMessageId != 4 {
+		t.Errorf("replies of comment 2 are wrong: %d", len(root.Replies[0].Replies))
+	}
+}
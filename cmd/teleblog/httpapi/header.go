@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views/partials"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// setHeaderChannel shows channel title and avatar in the header
+func setHeaderChannel(app core.App, header *partials.HeaderData, chat teleblog.Chat) error {
+	if chat.TgTitle == "" {
+		return nil
+	}
+
+	chatCollection, err := app.Dao().FindCollectionByNameOrId(chat.TableName())
+	if err != nil {
+		return err
+	}
+
+	header.ChannelTitle = chat.TgTitle
+	header.ChannelAvatarUrl = teleblog.ImagePath(chatCollection, &chat.BaseModel, chat.TgPhoto)
+
+	header.ChannelUrl = "/"
+
+	if chat.TgUsername != "" {
+		header.ChannelUrl = "https://t.me/" + chat.TgUsername
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/Dionid/teleblog/libs/file"
 	"github.com/labstack/echo/v5"
@@ -14,7 +15,9 @@ import (
 )
 
 type Config struct {
-	Env string
+	Env                  string
+	TelegramBotToken     string
+	VerificationTokenTTL time.Duration
 }
 
 func CacheControlMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
@@ -57,6 +60,10 @@ func InitApi(config Config, app core.App, gctx context.Context) {
 		IndexPageHandler(config, e, app)
 		SiteMapAndRobotsPageHandler(e, app)
 		PostPageHandler(e, app)
+		TelegramLinkHandler(e, app)
+		ChannelPageHandler(e, app)
+		VerificationLinkHandler(config, e, app)
+		PageHandler(e, app)
 
 		return nil
 	})
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
 	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
 	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views/partials"
 	"github.com/Dionid/teleblog/libs/teleblog"
@@ -23,6 +24,7 @@ type PostPageFilters struct {
 	PerPage int64  `query:"per_page"`
 	Search  string `query:"search"`
 	Tag     string `query:"tag"`
+	Channel string `query:"channel"`
 }
 
 func baseQuery(
@@ -30,53 +32,14 @@ func baseQuery(
 	filters PostPageFilters,
 	chatIds ...interface{},
 ) *dbx.SelectQuery {
-	// Query
-	baseQuery := teleblog.PostQuery(app.Dao()).
-		LeftJoin(
-			"comment",
-			dbx.NewExp("comment.post_id = post.id"),
-		).
-		Where(
-			dbx.In("post.chat_id", chatIds...),
-		).
-		// to avoid unsupported post types (video, photo, file, etc.)
-		AndWhere(
-			dbx.Or(
-				dbx.NewExp(`post.text != ""`), // THIS IS CRUCIAL
-				dbx.NewExp(`json_array_length(post.media) > 0`),
-			),
-		).
-		AndWhere(
-			dbx.NewExp("post.unparsable = false"),
-		)
-
-	// ## Filters
-
-	if filters.Search != "" {
-		baseQuery = baseQuery.AndWhere(
-			dbx.Or(
-				dbx.Like("post.text", filters.Search),
-				dbx.Like("comment.text", filters.Search),
-			),
-		)
-	}
-
-	if filters.Tag != "" {
-		baseQuery = baseQuery.
-			LeftJoin(
-				"post_tag",
-				dbx.NewExp("post_tag.post_id = post.id"),
-			).
-			LeftJoin(
-				"tag",
-				dbx.NewExp("tag.id = post_tag.tag_id"),
-			).
-			AndWhere(
-				dbx.HashExp{"tag.value": filters.Tag},
-			)
-	}
-
-	return baseQuery
+	return teleblog.PostsListQuery(
+		app.Dao(),
+		teleblog.PostsFilters{
+			Search: filters.Search,
+			Tag:    filters.Tag,
+		},
+		chatIds...,
+	)
 }
 
 func extractFirstURL(text string) string {
@@ -158,187 +121,266 @@ func fetchLinkPreview(url string) (*views.LinkPreview, error) {
 
 func IndexPageHandler(config Config, e *core.ServeEvent, app core.App) {
 	e.Router.GET("", func(c echo.Context) error {
-		// # Config
-		siteConfig := teleblog.Config{}
-
-		configCollection, err := teleblog.Configcollection(app.Dao())
-		if err != nil {
-			return fmt.Errorf("IndexPageHandler: get config collection error: %w", err)
-		}
-
-		err = teleblog.ConfigQuery(app.Dao()).One(&siteConfig)
-		if err != nil {
-			if strings.Contains(err.Error(), "no rows") {
-				return c.JSON(404, map[string]string{
-					"error": "Configuration not found",
-				})
-			}
+		return renderPostsListPage(c, app, "")
+	})
+}
 
-			return err
-		}
+// renderPostsListPage renders homepage or, if channelUsername is set, channel page
+func renderPostsListPage(c echo.Context, app core.App, channelUsername string) error {
+	// # Config
+	configCollection, err := teleblog.Configcollection(app.Dao())
+	if err != nil {
+		return fmt.Errorf("IndexPageHandler: get config collection error: %w", err)
+	}
 
-		if siteConfig.Id == "" {
+	// ## Config of the site on this host
+	siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
 			return c.JSON(404, map[string]string{
 				"error": "Configuration not found",
 			})
 		}
 
-		// # Get menu
-		menu := []teleblog.MenuItem{}
+		return err
+	}
 
-		err = teleblog.MenuItemQuery(app.Dao()).OrderBy("position").All(&menu)
-		if err != nil {
-			return err
+	if siteConfig.Id == "" {
+		return c.JSON(404, map[string]string{
+			"error": "Configuration not found",
+		})
+	}
+
+	// # Get menu
+	menu, err := teleblog.TenantMenuItems(app.Dao(), siteConfig)
+	if err != nil {
+		return err
+	}
+
+	// # Filters
+	var filters PostPageFilters
+
+	if err := c.Bind(&filters); err != nil {
+		return err
+	}
+
+	// # Get chats
+	chats := []teleblog.Chat{}
+
+	err = teleblog.TenantChatQuery(app.Dao(), siteConfig).AndWhere(
+		dbx.HashExp{"tg_type": []any{"channel", "privatechannel"}},
+	).All(&chats)
+	if err != nil {
+		return err
+	}
+
+	// ## Channel page or channel filter
+	var channel *teleblog.Chat
+
+	isChannelPage := channelUsername != ""
+
+	if !isChannelPage {
+		channelUsername = filters.Channel
+	}
+
+	if channelUsername != "" {
+		for i := range chats {
+			if strings.EqualFold(chats[i].TgUsername, channelUsername) {
+				channel = &chats[i]
+				break
+			}
 		}
 
-		// # Get chats
-		chats := []teleblog.Chat{}
+		if channel == nil {
+			if isChannelPage {
+				return c.JSON(404, map[string]string{
+					"error": "Channel not found",
+				})
+			}
 
-		err = teleblog.ChatQuery(app.Dao()).Where(
-			dbx.HashExp{"tg_type": "channel"},
-		).All(&chats)
-		if err != nil {
-			return err
+			chats = []teleblog.Chat{}
+		} else {
+			chats = []teleblog.Chat{*channel}
 		}
+	} else if len(siteConfig.HomepageChatIds) > 0 {
+		// ## Homepage channels
+		homepageChats := []teleblog.Chat{}
 
-		chatIds := []interface{}{}
 		for _, chat := range chats {
-			chatIds = append(chatIds, chat.Id)
+			for _, homepageChatId := range siteConfig.HomepageChatIds {
+				if chat.Id == homepageChatId {
+					homepageChats = append(homepageChats, chat)
+					break
+				}
+			}
 		}
 
-		// # Filters
-		var filters PostPageFilters
+		chats = homepageChats
+	}
 
-		if err := c.Bind(&filters); err != nil {
-			return err
-		}
+	chatIds := []interface{}{}
+	for _, chat := range chats {
+		chatIds = append(chatIds, chat.Id)
+	}
 
-		// ## Total
-		total := []struct {
-			Total int64 `db:"total"`
-		}{}
-
-		err = baseQuery(
-			app,
-			filters,
-			chatIds...,
-		).Select(
-			"count(post.id) as total",
-		).
-			GroupBy("post.album_id").
-			All(&total)
-		if err != nil {
-			return err
-		}
+	// ## Total
+	total := []struct {
+		Total int64 `db:"total"`
+	}{}
+
+	err = baseQuery(
+		app,
+		filters,
+		chatIds...,
+	).Select(
+		"count(post.id) as total",
+	).
+		GroupBy("post.album_id").
+		All(&total)
+	if err != nil {
+		return err
+	}
 
-		// ## Posts
-		posts := []*views.InpexPagePost{}
-		contentQuery := baseQuery(
-			app,
-			filters,
-			chatIds...,
-		).Select(
-			"post.id",
-			"post.album_id",
-			"chat.id as chat_id",
-			"post.tg_post_id",
-			"post.tg_group_message_id",
-			"post.created",
-			"chat.tg_username as tg_chat_username",
+	// ## Posts
+	posts := []*views.InpexPagePost{}
+	contentQuery := baseQuery(
+		app,
+		filters,
+		chatIds...,
+	).Select(
+		"post.id",
+		"post.album_id",
+		"chat.id as chat_id",
+		"post.tg_post_id",
+		"post.tg_group_message_id",
+		"post.created",
+		"chat.tg_username as tg_chat_username",
+		"chat.tg_title as tg_chat_title",
+		"chat.tg_photo as tg_chat_photo",
+	).
+		LeftJoin(
+			"chat",
+			dbx.NewExp("chat.id = post.chat_id"),
 		).
-			LeftJoin(
-				"chat",
-				dbx.NewExp("chat.id = post.chat_id"),
-			).
-			GroupBy("post.album_id").
-			OrderBy("post.created desc", "post.tg_post_id asc")
+		GroupBy("post.album_id").
+		OrderBy("post.created desc", "post.tg_post_id asc")
 
-		// ## Pagination
-		// ### Per page
-		perPage := filters.PerPage
+	// ## Pagination
+	// ### Per page
+	perPage := filters.PerPage
 
-		if perPage == 0 {
-			perPage = 10
-		} else if perPage > 100 {
-			perPage = 100
-		}
+	if perPage == 0 {
+		perPage = 10
+	} else if perPage > 100 {
+		perPage = 100
+	}
+
+	contentQuery = contentQuery.Limit(perPage)
+
+	// ## Current page
+	currentPage := filters.Page
+	if currentPage == 0 {
+		currentPage = 1
+	}
 
-		contentQuery = contentQuery.Limit(perPage)
+	contentQuery = contentQuery.Offset((currentPage - 1) * perPage)
 
-		// ## Current page
-		currentPage := filters.Page
-		if currentPage == 0 {
-			currentPage = 1
+	err = contentQuery.
+		All(&posts)
+	if err != nil {
+		return err
+	}
+
+	postCollection, err := app.Dao().FindCollectionByNameOrId("post")
+	if err != nil {
+		return err
+	}
+
+	chatCollection, err := app.Dao().FindCollectionByNameOrId("chat")
+	if err != nil {
+		return err
+	}
+
+	permalinks, err := teleblog.NewPermalinks(app.Dao(), siteConfig)
+	if err != nil {
+		return err
+	}
+
+	telegramPosts := newTelegramPostResolver(app, siteConfig, permalinks)
+
+	for _, post := range posts {
+		// # Channel badge
+		if post.TgChatPhoto != "" {
+			post.TgChatAvatarUrl = "/api/files/" + chatCollection.Id + "/" + post.ChatId + "/" + post.TgChatPhoto
 		}
 
-		contentQuery = contentQuery.Offset((currentPage - 1) * perPage)
+		type InnerPost struct {
+			teleblog.Post
+			CommentsCount int `db:"comments_count"`
+		}
 
-		err = contentQuery.
-			All(&posts)
+		innerPosts := []InnerPost{}
+
+		// THIS MUST CONTAIN ORIGINAL POST
+		err := teleblog.PostQuery(app.Dao()).
+			Select(
+				"post.*",
+				"count(comment.id) as comments_count",
+			).
+			LeftJoin(
+				"comment",
+				dbx.And(
+					dbx.NewExp("comment.post_id = post.id"),
+					teleblog.VisibleCommentExp(),
+				),
+			).
+			Where(
+				dbx.HashExp{"post.album_id": post.AlbumID},
+			).
+			GroupBy("post.id").
+			All(&innerPosts)
 		if err != nil {
-			return err
+			return fmt.Errorf("IndexPageHandler: get inner posts error: %w", err)
 		}
 
-		postCollection, err := app.Dao().FindCollectionByNameOrId("post")
-		if err != nil {
-			return err
+		for i, media := range post.Media {
+			post.Media[i] = postCollection.Id + "/" + post.Id + "/" + media
 		}
 
-		for _, post := range posts {
-			type InnerPost struct {
-				teleblog.Post
-				CommentsCount int `db:"comments_count"`
+		for _, innerPost := range innerPosts {
+			if innerPost.Text != "" {
+				post.Slug = innerPost.Slug
 			}
 
-			innerPosts := []InnerPost{}
+			// # Text
+			post.Text += innerPost.Text + "\n\n"
 
-			// THIS MUST CONTAIN ORIGINAL POST
-			err := teleblog.PostQuery(app.Dao()).
-				Select(
-					"post.*",
-					"count(comment.id) as comments_count",
-				).
-				LeftJoin(
-					"comment",
-					dbx.NewExp("comment.post_id = post.id"),
-				).
-				Where(
-					dbx.HashExp{"post.album_id": post.AlbumID},
-				).
-				GroupBy("post.id").
-				All(&innerPosts)
-			if err != nil {
-				return fmt.Errorf("IndexPageHandler: get inner posts error: %w", err)
+			if innerPost.IsTgHistoryMessage {
+				post.IsTgHistoryMessage = innerPost.IsTgHistoryMessage
 			}
 
-			for i, media := range post.Media {
-				post.Media[i] = postCollection.Id + "/" + post.Id + "/" + media
-			}
+			// # Photos
+			medias := []string{}
 
-			for _, innerPost := range innerPosts {
-				if innerPost.Text != "" {
-					post.Slug = innerPost.Slug
-				}
+			if innerPost.Cover != "" {
+				medias = append(medias, postCollection.Id+"/"+innerPost.Id+"/"+innerPost.Cover)
+			}
 
-				// # Text
-				post.Text += innerPost.Text + "\n\n"
+			for _, media := range innerPost.Media {
+				medias = append(medias, postCollection.Id+"/"+innerPost.Id+"/"+media)
+			}
 
-				if innerPost.IsTgHistoryMessage {
-					post.IsTgHistoryMessage = innerPost.IsTgHistoryMessage
-				}
+			post.Media = append(post.Media, medias...)
 
-				// # Photos
-				medias := []string{}
+			// # Markup
+			markup := ""
 
-				for _, media := range innerPost.Media {
-					medias = append(medias, postCollection.Id+"/"+innerPost.Id+"/"+media)
+			if innerPost.IsWebPost() {
+				markup, err = teleblog.MarkdownToHtml(innerPost.Markdown)
+				if err != nil {
+					return err
 				}
-
-				post.Media = append(post.Media, medias...)
-
-				// # Markup
-				markup := ""
-
+			} else {
 				jb, err := innerPost.TgMessageRaw.MarshalJSON()
 				if err != nil {
 					return err
@@ -350,11 +392,7 @@ func IndexPageHandler(config Config, e *core.ServeEvent, app core.App) {
 					err = json.Unmarshal(jb, &rawMessage)
 					if err != nil {
 						app.Logger().Error("IndexPageHandler: unmarshal history message error", "error", err, "post_id", post.Id)
-						_, err := app.DB().Update(
-							"post",
-							dbx.Params{"unparsable": true},
-							dbx.HashExp{"id": innerPost.Id},
-						).Execute()
+						err = features.MarkPostUnparsable(app, &innerPost.Post, err)
 						if err != nil {
 							return fmt.Errorf("IndexPageHandler: update post error: %w", err)
 						}
@@ -372,11 +410,7 @@ func IndexPageHandler(config Config, e *core.ServeEvent, app core.App) {
 					err = json.Unmarshal(jb, &rawMessage)
 					if err != nil {
 						app.Logger().Error("IndexPageHandler: unmarshal history message error", "error", err, "post_id", post.Id)
-						_, err := app.DB().Update(
-							"post",
-							dbx.Params{"unparsable": true},
-							dbx.HashExp{"id": innerPost.Id},
-						).Execute()
+						err = features.MarkPostUnparsable(app, &innerPost.Post, err)
 						if err != nil {
 							return fmt.Errorf("IndexPageHandler: update post error: %w", err)
 						}
@@ -395,110 +429,160 @@ func IndexPageHandler(config Config, e *core.ServeEvent, app core.App) {
 						}
 					}
 				}
+			}
 
-				post.TextWithMarkup += markup
+			post.TextWithMarkup += markup
 
-				// # Comments count
-				post.CommentsCount += innerPost.CommentsCount
-			}
+			// # Comments count
+			post.CommentsCount += innerPost.CommentsCount
+		}
 
-			post.Text = strings.ReplaceAll(post.Text, "\n", "<br>")
+		post.Text = strings.ReplaceAll(post.Text, "\n", "<br>")
 
-			// Extract and fetch link preview
-			if url := extractFirstURL(post.Text); url != "" {
-				if preview, err := fetchLinkPreview(url); err == nil {
-					post.LinkPreview = preview
-				}
+		post.Url = permalinks.PostPath(post.Post)
+
+		// # Links to Telegram posts hosted on the blog
+		post.TextWithMarkup, err = telegramPosts.RewriteLinks(post.TextWithMarkup)
+		if err != nil {
+			return err
+		}
+
+		// Extract and fetch link preview
+		if url := extractFirstURL(post.Text); url != "" {
+			if preview, err := fetchLinkPreview(url); err == nil {
+				post.LinkPreview = preview
 			}
 		}
+	}
 
-		// # Tags
+	// # Tags
 
-		tags := []*teleblog.Tag{}
+	tags := []*teleblog.Tag{}
 
-		err = teleblog.TagQuery(app.Dao()).
-			Select("tag.value").
-			LeftJoin(
-				"post_tag",
-				dbx.NewExp("post_tag.tag_id = tag.id"),
-			).
-			Where(
-				dbx.In("post_tag.chat_id", chatIds...),
-			).
-			OrderBy("tag.created desc").
-			GroupBy("tag.value").
-			All(&tags)
+	err = teleblog.TagQuery(app.Dao()).
+		Select("tag.value").
+		LeftJoin(
+			"post_tag",
+			dbx.NewExp("post_tag.tag_id = tag.id"),
+		).
+		Where(
+			dbx.In("post_tag.chat_id", chatIds...),
+		).
+		OrderBy("tag.created desc").
+		GroupBy("tag.value").
+		All(&tags)
+	if err != nil {
+		return err
+	}
+
+	pagination := views.PaginationData{
+		Total:       int64(len(total)),
+		PerPage:     perPage,
+		CurrentPage: currentPage,
+	}
+
+	// # Render component
+	// ## Header
+	header := partials.HeaderData{
+		LogoUrl: teleblog.ImagePath(
+			configCollection,
+			&siteConfig.BaseModel,
+			siteConfig.LogoUrl,
+		),
+		LogoAlt:   siteConfig.LogoAlt,
+		MenuItems: []partials.HeaderMenuItem{},
+	}
+
+	for _, item := range menu {
+		header.MenuItems = append(header.MenuItems, partials.HeaderMenuItem{
+			Name: item.Name,
+			Url:  item.Url,
+		})
+	}
+
+	// ## Single channel blog or channel page
+	if len(chats) == 1 {
+		err = setHeaderChannel(app, &header, chats[0])
 		if err != nil {
 			return err
 		}
+	}
+
+	// ## Page info
+	seo := views.SeoMetadata{
+		Title:       siteConfig.SeoTitle,
+		Description: siteConfig.SeoDescription,
+		Image: teleblog.ImagePath(
+			configCollection,
+			&siteConfig.BaseModel,
+			siteConfig.SeoImage,
+		),
+		Url:  siteConfig.SeoUrl,
+		Type: "website",
+	}
+
+	canonicalUrl := ""
+
+	info := views.IndexPageInfo{
+		Description:       siteConfig.Description,
+		SelectedTag:       filters.Tag,
+		TextSearch:        filters.Search,
+		ShowChannelBadges: len(chats) > 1,
+		ResetUrl:          "/",
+		Header:            header,
+		Footer: partials.FooterData{
+			Text: siteConfig.Footer,
+		},
+	}
+
+	// ## Channel page
+	if isChannelPage {
+		channelUrl := siteConfig.SiteUrl(app.Settings().Meta.AppUrl) + "/c/" + channel.TgUsername
+
+		info.Description = ""
+		info.ChannelDescription = channel.TgDescription
+		info.ResetUrl = "/c/" + channel.TgUsername
 
-		pagination := views.PaginationData{
-			Total:       int64(len(total)),
-			PerPage:     perPage,
-			CurrentPage: currentPage,
+		if channel.TgTitle != "" {
+			seo.Title = channel.TgTitle
 		}
 
-		// # Render component
-		// ## Header
-		header := partials.HeaderData{
-			LogoUrl: teleblog.ImagePath(
-				configCollection,
-				&siteConfig.BaseModel,
-				siteConfig.LogoUrl,
-			),
-			LogoAlt:   siteConfig.LogoAlt,
-			MenuItems: []partials.HeaderMenuItem{},
+		if channel.TgDescription != "" {
+			seo.Description = channel.TgDescription
 		}
 
-		for _, item := range menu {
-			header.MenuItems = append(header.MenuItems, partials.HeaderMenuItem{
-				Name: item.Name,
-				Url:  item.Url,
-			})
+		if channel.TgPhoto != "" {
+			seo.Image = header.ChannelAvatarUrl
 		}
 
-		component := views.IndexPage(
-			views.BaseLayoutData{
-				Seo: views.SeoMetadata{
-					Title:       siteConfig.SeoTitle,
-					Description: siteConfig.SeoDescription,
-					Image: teleblog.ImagePath(
-						configCollection,
-						&siteConfig.BaseModel,
-						siteConfig.SeoImage,
-					),
-					Url:  siteConfig.SeoUrl,
-					Type: "website",
-				},
-				YandexMetrikaCounter:   siteConfig.YandexMetrikaCounter,
-				GoogleAnalyticsCounter: siteConfig.GoogleAnalyticsCounter,
-				PrimaryColor:           siteConfig.PrimaryColor,
-				BgImage: teleblog.ImagePath(
-					configCollection,
-					&siteConfig.BaseModel,
-					siteConfig.BgImage,
-				),
-				FavIcon: teleblog.ImagePath(
-					configCollection,
-					&siteConfig.BaseModel,
-					siteConfig.Favicon,
-				),
-				CustomCss: siteConfig.CustomCss,
-			},
-			views.IndexPageInfo{
-				Description: siteConfig.Description,
-				SelectedTag: filters.Tag,
-				TextSearch:  filters.Search,
-				Header:      header,
-				Footer: partials.FooterData{
-					Text: siteConfig.Footer,
-				},
-			},
-			pagination,
-			posts,
-			tags,
-		)
-
-		return component.Render(c.Request().Context(), c.Response().Writer)
-	})
+		seo.Url = channelUrl
+		canonicalUrl = channelUrl
+	}
+
+	component := views.IndexPage(
+		views.BaseLayoutData{
+			Seo:                    seo,
+			CanonicalUrl:           canonicalUrl,
+			YandexMetrikaCounter:   siteConfig.YandexMetrikaCounter,
+			GoogleAnalyticsCounter: siteConfig.GoogleAnalyticsCounter,
+			PrimaryColor:           siteConfig.PrimaryColor,
+			BgImage: teleblog.ImagePath(
+				configCollection,
+				&siteConfig.BaseModel,
+				siteConfig.BgImage,
+			),
+			FavIcon: teleblog.ImagePath(
+				configCollection,
+				&siteConfig.BaseModel,
+				siteConfig.Favicon,
+			),
+			CustomCss: siteConfig.CustomCss,
+		},
+		info,
+		pagination,
+		posts,
+		tags,
+	)
+
+	return component.Render(c.Request().Context(), c.Response().Writer)
 }
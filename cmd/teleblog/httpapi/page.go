@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views/partials"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PageHandler serves static pages (About, Contacts, etc.) of the site
+// and post permalinks of site patterns, e.g. /:year/:month/:slug
+func PageHandler(e *core.ServeEvent, app core.App) {
+	e.Router.GET("/:slug", func(c echo.Context) error {
+		// # Pages have one segment, permalinks have more
+		if strings.Contains(c.PathParam("slug"), "/") {
+			return servePermalink(c, app)
+		}
+
+		// # Site config collection
+		siteConfigCollection, err := teleblog.Configcollection(app.Dao())
+		if err != nil {
+			return fmt.Errorf("PageHandler: get config collection error: %w", err)
+		}
+
+		// # Config of the site on this host
+		siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(404, map[string]string{
+					"error": "Configuration not found",
+				})
+			}
+
+			return err
+		}
+
+		page := teleblog.Page{}
+
+		err = teleblog.TenantPageQuery(app.Dao(), siteConfig).
+			AndWhere(dbx.HashExp{"slug": c.PathParam("slug")}).
+			Limit(1).
+			One(&page)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(404, map[string]string{
+					"error": "Page not found",
+				})
+			}
+
+			return err
+		}
+
+		textWithMarkup, err := teleblog.MarkdownToHtml(page.Markdown)
+		if err != nil {
+			return err
+		}
+
+		// # Get menu
+		menu, err := teleblog.TenantMenuItems(app.Dao(), siteConfig)
+		if err != nil {
+			return err
+		}
+
+		// # SEO
+		siteUrl := siteConfig.SiteUrl(app.Settings().Meta.AppUrl)
+
+		seo := views.SeoMetadata{
+			Title:       templu.OrDefaultString(page.SeoTitle, page.Title),
+			Description: page.SeoDescription,
+			Image: teleblog.ImagePath(
+				siteConfigCollection,
+				&siteConfig.BaseModel,
+				siteConfig.SeoImage,
+			),
+			Url:  siteUrl + teleblog.PagePath(page),
+			Type: "website",
+		}
+
+		if seo.Description == "" {
+			text, err := teleblog.MarkdownToText(page.Markdown)
+			if err != nil {
+				return err
+			}
+
+			seo.Description = templu.RemoveNewLines(fmt.Sprintf("%.160s", text))
+		}
+
+		// # Header
+		header := partials.HeaderData{
+			LogoUrl: teleblog.ImagePath(
+				siteConfigCollection,
+				&siteConfig.BaseModel,
+				siteConfig.LogoUrl,
+			),
+			LogoAlt:   siteConfig.LogoAlt,
+			MenuItems: []partials.HeaderMenuItem{},
+		}
+
+		for _, item := range menu {
+			header.MenuItems = append(header.MenuItems, partials.HeaderMenuItem{
+				Name: item.Name,
+				Url:  item.Url,
+			})
+		}
+
+		component := views.ContentPage(
+			views.BaseLayoutData{
+				Seo:                    seo,
+				YandexMetrikaCounter:   siteConfig.YandexMetrikaCounter,
+				GoogleAnalyticsCounter: siteConfig.GoogleAnalyticsCounter,
+				PrimaryColor:           siteConfig.PrimaryColor,
+				BgImage: teleblog.ImagePath(
+					siteConfigCollection,
+					&siteConfig.BaseModel,
+					siteConfig.BgImage,
+				),
+				CustomCss: siteConfig.CustomCss,
+				FavIcon: teleblog.ImagePath(
+					siteConfigCollection,
+					&siteConfig.BaseModel,
+					siteConfig.Favicon,
+				),
+				CanonicalUrl: siteUrl + teleblog.PagePath(page),
+			},
+			views.ContentPageData{
+				Header: header,
+				Footer: partials.FooterData{
+					Text: siteConfig.Footer,
+				},
+				Title:          page.Title,
+				TextWithMarkup: textWithMarkup,
+			},
+		)
+
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	})
+}
@@ -0,0 +1,275 @@
+package httpapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/Dionid/teleblog/libs/templu"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Replies deeper than this are not followed to find the first post of the series
+const maxPostSeriesDepth = 50
+
+// Series longer than this are cut
+const maxPostSeriesSize = 100
+
+// postCardTitle returns title of the post for cards and navigation
+func postCardTitle(post teleblog.Post) string {
+	title := post.Title
+
+	if title == "" {
+		title = templu.RemoveNewLines(fmt.Sprintf("%.60s", post.Text))
+	}
+
+	if title == "" {
+		title = post.Created.Time().Format("2006-01-02 15:04")
+	}
+
+	return title
+}
+
+// chatPostsQuery selects visible posts of the chat on the site
+func chatPostsQuery(app core.App, siteConfig *teleblog.Config, chatId string) *dbx.SelectQuery {
+	return teleblog.PostQuery(app.Dao()).
+		Where(dbx.HashExp{"post.chat_id": chatId}).
+		AndWhere(dbx.NewExp("post.unparsable = false AND post.hidden = false")).
+		AndWhere(teleblog.TenantPostExp(siteConfig))
+}
+
+// findChatPost finds visible post of the chat by its Telegram id,
+// nil is returned if it is not on the site
+func findChatPost(app core.App, siteConfig *teleblog.Config, chatId string, tgPostId int) (*teleblog.Post, error) {
+	post := &teleblog.Post{}
+
+	err := chatPostsQuery(app, siteConfig, chatId).
+		AndWhere(dbx.HashExp{"post.tg_post_id": tgPostId}).
+		Limit(1).
+		One(post)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// referenceCard makes card of the message, it links to the post
+// on the site if it is hosted, otherwise to Telegram
+func referenceCard(resolver *telegramPostResolver, reference *teleblog.MessageReference, quote string) (*views.PostContextCard, error) {
+	card := &views.PostContextCard{
+		Title: reference.Title,
+		Url:   reference.TelegramUrl(),
+		Quote: quote,
+	}
+
+	if !reference.Date.IsZero() {
+		card.Date = reference.Date.Format("2006-01-02 15:04")
+	}
+
+	if reference.Username != "" && reference.TgPostId != 0 {
+		path, err := resolver.Resolve(reference.Username, reference.TgPostId)
+		if err != nil {
+			return nil, err
+		}
+
+		if path != "" {
+			card.Url = path
+			card.Internal = true
+		}
+	}
+
+	return card, nil
+}
+
+// loadPostContextCards fills cards of the post the page post replies to
+// and of the message it is forwarded from
+func loadPostContextCards(
+	app core.App,
+	siteConfig *teleblog.Config,
+	permalinks *teleblog.Permalinks,
+	chat teleblog.Chat,
+	posts []teleblog.Post,
+	data *views.PostPageData,
+) error {
+	resolver := newTelegramPostResolver(app, siteConfig, permalinks)
+
+	// # References are stored in one of the album posts
+	for _, post := range posts {
+		references, err := teleblog.ParsePostReferences(post)
+		if err != nil {
+			return err
+		}
+
+		if data.ReplyTo == nil && references.ReplyToTgPostId != 0 {
+			repliedPost, err := findChatPost(app, siteConfig, chat.Id, references.ReplyToTgPostId)
+			if err != nil {
+				return err
+			}
+
+			if repliedPost != nil {
+				data.ReplyTo = &views.PostContextCard{
+					Title:    postCardTitle(*repliedPost),
+					Url:      permalinks.PostPath(*repliedPost),
+					Quote:    references.Quote,
+					Date:     repliedPost.Created.Time().Format("2006-01-02 15:04"),
+					Internal: true,
+				}
+			} else {
+				data.ReplyTo, err = referenceCard(resolver, &teleblog.MessageReference{
+					Title:    chat.TgTitle,
+					Username: chat.TgUsername,
+					TgPostId: references.ReplyToTgPostId,
+				}, references.Quote)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if data.ReplyTo == nil && references.ExternalReply != nil {
+			data.ReplyTo, err = referenceCard(resolver, references.ExternalReply, references.Quote)
+			if err != nil {
+				return err
+			}
+		}
+
+		if data.ForwardedFrom == nil && references.ForwardOrigin != nil {
+			data.ForwardedFrom, err = referenceCard(resolver, references.ForwardOrigin, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// albumsTgPostIds returns Telegram ids of all posts of the albums,
+// replies can be made to any of them
+func albumsTgPostIds(app core.App, siteConfig *teleblog.Config, chatId string, albumIds []any) ([]any, error) {
+	tgPostIds := []int{}
+
+	err := chatPostsQuery(app, siteConfig, chatId).
+		Select("post.tg_post_id").
+		AndWhere(dbx.In("post.album_id", albumIds...)).
+		AndWhere(dbx.NewExp("post.tg_post_id != 0")).
+		Column(&tgPostIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []any{}
+	for _, tgPostId := range tgPostIds {
+		result = append(result, tgPostId)
+	}
+
+	return result, nil
+}
+
+// loadPostSeries returns posts of the reply chain of the page post:
+// the first post of the chain and all replies to it, one per album
+func loadPostSeries(
+	app core.App,
+	siteConfig *teleblog.Config,
+	permalinks *teleblog.Permalinks,
+	posts []teleblog.Post,
+) ([]views.PostSeriesItem, error) {
+	current := posts[0]
+
+	// # Web posts can't reply and be replied
+	if current.TgMessageId == 0 {
+		return nil, nil
+	}
+
+	// # Reply is stored in one of the album posts
+	root := current
+	for _, post := range posts {
+		if post.TgReplyToPostId != 0 {
+			root = post
+			break
+		}
+	}
+
+	for depth := 0; root.TgReplyToPostId != 0 && depth < maxPostSeriesDepth; depth++ {
+		parent, err := findChatPost(app, siteConfig, root.ChatId, root.TgReplyToPostId)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent == nil {
+			break
+		}
+
+		root = *parent
+	}
+
+	series := []teleblog.Post{root}
+	seenAlbums := map[string]bool{root.AlbumID: true}
+
+	level, err := albumsTgPostIds(app, siteConfig, root.ChatId, []any{root.AlbumID})
+	if err != nil {
+		return nil, err
+	}
+
+	for len(level) > 0 && len(series) < maxPostSeriesSize {
+		replies := []teleblog.Post{}
+
+		err := chatPostsQuery(app, siteConfig, root.ChatId).
+			AndWhere(dbx.In("post.tg_reply_to_post_id", level...)).
+			OrderBy("post.created asc").
+			All(&replies)
+		if err != nil {
+			return nil, err
+		}
+
+		albumIds := []any{}
+
+		for _, reply := range replies {
+			if seenAlbums[reply.AlbumID] || len(series) >= maxPostSeriesSize {
+				continue
+			}
+
+			seenAlbums[reply.AlbumID] = true
+			albumIds = append(albumIds, reply.AlbumID)
+			series = append(series, reply)
+		}
+
+		if len(albumIds) == 0 {
+			break
+		}
+
+		level, err = albumsTgPostIds(app, siteConfig, root.ChatId, albumIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(series) < 2 {
+		return nil, nil
+	}
+
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Created.Time().Before(series[j].Created.Time())
+	})
+
+	items := []views.PostSeriesItem{}
+
+	for _, post := range series {
+		items = append(items, views.PostSeriesItem{
+			Title:   postCardTitle(post),
+			Url:     permalinks.PostPath(post),
+			Date:    post.Created.Time().Format("2006-01-02"),
+			Current: post.AlbumID == current.AlbumID,
+		})
+	}
+
+	return items, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"strconv"
 	"strings"
 
 	"github.com/Dionid/teleblog/cmd/teleblog/httpapi/views"
@@ -16,320 +17,402 @@ import (
 	"gopkg.in/telebot.v4"
 )
 
-func PostPageHandler(e *core.ServeEvent, app core.App) {
-	e.Router.GET("/post/:id", func(c echo.Context) error {
-		// # Site config collection
-		siteConfigCollection, err := teleblog.Configcollection(app.Dao())
-		if err != nil {
-			return fmt.Errorf("IndexPageHandler: get config collection error: %w", err)
+// findPermalinkPost finds visible post of the site by values
+// of the permalink placeholders, nil is returned if there is none
+func findPermalinkPost(app core.App, siteConfig *teleblog.Config, params map[string]string) (*views.PostPagePost, error) {
+	exps := []dbx.Expression{
+		dbx.NewExp("post.unparsable = false AND post.hidden = false"),
+		teleblog.TenantPostExp(siteConfig),
+	}
+
+	if channel, ok := params[teleblog.PermalinkChannel]; ok {
+		exps = append(exps, dbx.NewExp(
+			"post.chat_id IN (SELECT id FROM chat WHERE id = {:channel} OR tg_username = {:channel} COLLATE NOCASE)",
+			dbx.Params{"channel": channel},
+		))
+	}
+
+	if postId, ok := params[teleblog.PermalinkId]; ok {
+		exps = append(exps, dbx.HashExp{"post.id": postId})
+	}
+
+	// # Web posts are linked by id
+	if tgPostId, ok := params[teleblog.PermalinkTgPostId]; ok {
+		if _, err := strconv.Atoi(tgPostId); err == nil {
+			exps = append(exps, dbx.HashExp{"post.tg_post_id": tgPostId})
+		} else {
+			exps = append(exps, dbx.HashExp{"post.id": tgPostId})
+		}
+	}
+
+	// # Post is found by slug, then by id (posts without slug are linked by it),
+	// then by old slug, so id can't shadow slug of other post
+	variants := [][]dbx.Expression{{}}
+
+	if postSlug, ok := params[teleblog.PermalinkSlug]; ok {
+		variants = [][]dbx.Expression{
+			{dbx.HashExp{"post.slug": postSlug}},
+			{dbx.HashExp{"post.id": postSlug}},
+			{dbx.NewExp(
+				"post.id IN (SELECT post_id FROM post_slug_redirect WHERE slug = {:slug})",
+				dbx.Params{"slug": postSlug},
+			)},
+		}
+	}
+
+	for _, variant := range variants {
+		post := &views.PostPagePost{}
+
+		err := teleblog.PostQuery(app.Dao()).
+			Where(dbx.And(append(exps, variant...)...)).
+			OrderBy("post.created asc").
+			Limit(1).
+			One(post)
+		if err == nil {
+			return post, nil
 		}
 
-		// # Config
-		siteConfig := teleblog.Config{}
-
-		err = teleblog.ConfigQuery(app.Dao()).One(&siteConfig)
-		if err != nil {
-			return err
+		if !strings.Contains(err.Error(), "no rows") {
+			return nil, err
 		}
+	}
+
+	return nil, nil
+}
 
-		if siteConfig.Id == "" {
+// servePermalink renders post found by the path or redirects to its
+// permalink if the path is of old pattern, date or slug
+func servePermalink(c echo.Context, app core.App) error {
+	// # Config of the site on this host
+	siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
 			return c.JSON(404, map[string]string{
 				"error": "Configuration not found",
 			})
 		}
 
-		// # Get menu
-		menu := []teleblog.MenuItem{}
+		return err
+	}
 
-		err = teleblog.MenuItemQuery(app.Dao()).OrderBy("position").All(&menu)
-		if err != nil {
-			return err
-		}
+	if siteConfig.Id == "" {
+		return c.JSON(404, map[string]string{
+			"error": "Configuration not found",
+		})
+	}
 
-		// # Get post by ID or slug
-		postIdOrSlug := c.PathParam("id")
+	permalinks, err := teleblog.NewPermalinks(app.Dao(), siteConfig)
+	if err != nil {
+		return err
+	}
 
-		post := views.PostPagePost{}
-		err = teleblog.PostQuery(app.Dao()).Where(
-			dbx.Or(
-				dbx.HashExp{"id": postIdOrSlug},
-				dbx.HashExp{"slug": postIdOrSlug},
-			),
-		).AndWhere(
-			dbx.NewExp("unparsable = false"),
-		).Limit(1).One(&post)
-		if err != nil {
-			return err
-		}
-
-		// # Correct post media URLs
-		postCollection, err := app.Dao().FindCollectionByNameOrId("post")
-		if err != nil {
-			return err
-		}
+	path := c.Request().URL.Path
 
-		for i, media := range post.Media {
-			post.Media[i] = "/api/files/" + postCollection.Id + "/" + post.Id + "/" + media
+	for _, pattern := range permalinks.Patterns() {
+		params, ok := teleblog.MatchPermalink(pattern, path)
+		if !ok {
+			continue
 		}
 
-		// # Get album posts
-		albumPosts := []*views.PostPagePost{}
-		err = teleblog.PostQuery(app.Dao()).Where(
-			dbx.HashExp{"album_id": post.AlbumID},
-		).AndWhere(
-			dbx.Not(
-				dbx.HashExp{"id": post.Id},
-			),
-		).All(&albumPosts)
+		post, err := findPermalinkPost(app, siteConfig, params)
 		if err != nil {
 			return err
 		}
 
-		// # Correct media URLs for album posts
-		for _, albumPost := range albumPosts {
-			for i, media := range albumPost.Media {
-				albumPost.Media[i] = "/api/files/" + postCollection.Id + "/" + albumPost.Id + "/" + media
-			}
-
-			post.Media = append(post.Media, albumPost.Media...)
+		if post == nil {
+			continue
 		}
 
-		// # Remarshal JSON to correct type
-		jb, err := post.Post.TgMessageRaw.MarshalJSON()
-		if err != nil {
-			return err
-		}
+		postPath := permalinks.PostPath(post.Post)
 
-		// # Text with markup
-		if post.IsTgHistoryMessage {
-			rawMessage := teleblog.HistoryMessage{}
+		if postPath != path {
+			return c.Redirect(301, postPath)
+		}
 
-			err = json.Unmarshal(jb, &rawMessage)
-			if err != nil {
-				return err
-			}
+		return renderPostPage(c, app, siteConfig, permalinks, *post)
+	}
 
-			if len(rawMessage.Text.Items) > 0 {
-				post.TextWithMarkup = teleblog.FormHistoryRawTextWithMarkup(rawMessage.Text)
-			} else if len(rawMessage.TextEntities) > 0 {
-				post.TextWithMarkup = teleblog.HistoryTextEntitiesWithToTextWithMarkup(rawMessage.TextEntities)
-			} else {
-				post.TextWithMarkup = strings.ReplaceAll(
-					html.EscapeString(rawMessage.Title),
-					"\n",
-					"<br>",
-				)
-			}
-		} else {
-			rawMessage := telebot.Message{}
+	return c.JSON(404, map[string]string{
+		"error": "Post not found",
+	})
+}
 
-			err = json.Unmarshal(jb, &rawMessage)
-			if err != nil {
-				return err
-			}
+// PostPageHandler serves default permalinks, they are redirected
+// if site has own pattern. Permalinks of other patterns are served
+// by PageHandler, because its last param matches the rest of the path.
+func PostPageHandler(e *core.ServeEvent, app core.App) {
+	e.Router.GET("/post/:id", func(c echo.Context) error {
+		return servePermalink(c, app)
+	})
+}
 
-			if len(rawMessage.Entities) > 0 {
-				post.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Text, rawMessage.Entities)
-				if err != nil {
-					return err
-				}
-			} else if len(rawMessage.CaptionEntities) > 0 {
-				post.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Caption, rawMessage.CaptionEntities)
-				if err != nil {
-					return err
-				}
-			} else {
-				post.TextWithMarkup = strings.ReplaceAll(
-					html.EscapeString(rawMessage.Text),
-					"\n",
-					"<br>",
-				)
-			}
+func renderPostPage(
+	c echo.Context,
+	app core.App,
+	siteConfig *teleblog.Config,
+	permalinks *teleblog.Permalinks,
+	post views.PostPagePost,
+) error {
+	// # Site config collection
+	siteConfigCollection, err := teleblog.Configcollection(app.Dao())
+	if err != nil {
+		return fmt.Errorf("PostPageHandler: get config collection error: %w", err)
+	}
+
+	// # Get menu
+	menu, err := teleblog.TenantMenuItems(app.Dao(), siteConfig)
+	if err != nil {
+		return err
+	}
+
+	// # Correct post media URLs
+	postCollection, err := app.Dao().FindCollectionByNameOrId("post")
+	if err != nil {
+		return err
+	}
+
+	for i, media := range post.Media {
+		post.Media[i] = "/api/files/" + postCollection.Id + "/" + post.Id + "/" + media
+	}
+
+	// # Get album posts
+	albumPosts := []*views.PostPagePost{}
+	err = teleblog.PostQuery(app.Dao()).Where(
+		dbx.HashExp{"album_id": post.AlbumID},
+	).AndWhere(
+		dbx.Not(
+			dbx.HashExp{"id": post.Id},
+		),
+	).All(&albumPosts)
+	if err != nil {
+		return err
+	}
+
+	// # Correct media URLs for album posts
+	for _, albumPost := range albumPosts {
+		for i, media := range albumPost.Media {
+			albumPost.Media[i] = "/api/files/" + postCollection.Id + "/" + albumPost.Id + "/" + media
 		}
 
-		// Extract and fetch link preview
-		if url := extractFirstURL(post.Text); url != "" {
-			if preview, err := fetchLinkPreview(url); err == nil {
-				post.LinkPreview = preview
-			}
-		}
+		post.Media = append(post.Media, albumPost.Media...)
+	}
 
-		// # Get comments from group chat
-		chat := teleblog.Chat{}
+	// # Cover of the web post
+	if post.Cover != "" {
+		post.Media = append([]string{"/api/files/" + postCollection.Id + "/" + post.Id + "/" + post.Cover}, post.Media...)
+	}
 
-		err = teleblog.ChatQuery(app.Dao()).Where(
-			dbx.HashExp{
-				"id": post.ChatId,
-			},
-		).Limit(1).One(&chat)
+	// # Remarshal JSON to correct type
+	jb, err := post.Post.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	// # Text with markup
+	if post.IsWebPost() {
+		post.TextWithMarkup, err = teleblog.MarkdownToHtml(post.Markdown)
 		if err != nil {
 			return err
 		}
+	} else if post.IsTgHistoryMessage {
+		rawMessage := teleblog.HistoryMessage{}
 
-		// # Get comments for post
-		comments := []*views.PostPageComment{}
+		err = json.Unmarshal(jb, &rawMessage)
+		if err != nil {
+			return err
+		}
 
-		postsIds := []any{post.Id}
-		if len(albumPosts) > 0 {
-			for _, albumPost := range albumPosts {
-				postsIds = append(postsIds, albumPost.Id)
-			}
+		if len(rawMessage.Text.Items) > 0 {
+			post.TextWithMarkup = teleblog.FormHistoryRawTextWithMarkup(rawMessage.Text)
+		} else if len(rawMessage.TextEntities) > 0 {
+			post.TextWithMarkup = teleblog.HistoryTextEntitiesWithToTextWithMarkup(rawMessage.TextEntities)
+		} else {
+			post.TextWithMarkup = strings.ReplaceAll(
+				html.EscapeString(rawMessage.Title),
+				"\n",
+				"<br>",
+			)
 		}
+	} else {
+		rawMessage := telebot.Message{}
 
-		err = teleblog.CommentQuery(app.Dao()).Where(
-			dbx.In("post_id", postsIds...),
-		).All(&comments)
+		err = json.Unmarshal(jb, &rawMessage)
 		if err != nil {
-			return fmt.Errorf("PostPageHandler: get comments error: %w", err)
+			return err
 		}
 
-		// # Prepare comments
-		for _, comment := range comments {
-			jb, err := comment.TgMessageRaw.MarshalJSON()
+		if len(rawMessage.Entities) > 0 {
+			post.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Text, rawMessage.Entities)
 			if err != nil {
-				return fmt.Errorf("PostPageHandler: marshal comment error: %w", err)
+				return err
 			}
-
-			if comment.IsTgHistoryMessage {
-				rawMessage := teleblog.HistoryMessage{}
-
-				err = json.Unmarshal(jb, &rawMessage)
-				if err != nil {
-					return fmt.Errorf("PostPageHandler: unmarshal history message error: %w", err)
-				}
-
-				comment.AuthorTitle = rawMessage.From
-
-				if len(rawMessage.Text.Items) > 0 {
-					comment.TextWithMarkup = teleblog.FormHistoryRawTextWithMarkup(rawMessage.Text)
-				} else {
-					comment.TextWithMarkup = teleblog.HistoryTextEntitiesWithToTextWithMarkup(rawMessage.TextEntities)
-				}
-			} else {
-				rawMessage := telebot.Message{}
-
-				err = json.Unmarshal(jb, &rawMessage)
-				if err != nil {
-					return err
-				}
-
-				if rawMessage.Sender.IsBot && rawMessage.SenderChat != nil {
-					comment.AuthorTitle = rawMessage.SenderChat.Title
-					comment.AuthorUsername = &rawMessage.SenderChat.Username
-				} else {
-					comment.AuthorTitle = rawMessage.Sender.FirstName + " " + rawMessage.Sender.LastName
-					comment.AuthorUsername = &rawMessage.Sender.Username
-				}
-
-				if len(rawMessage.Entities) > 0 {
-					comment.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Text, rawMessage.Entities)
-					if err != nil {
-						return err
-					}
-				} else if len(rawMessage.CaptionEntities) > 0 {
-					comment.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Caption, rawMessage.CaptionEntities)
-					if err != nil {
-						return err
-					}
-				} else {
-					comment.TextWithMarkup = strings.ReplaceAll(
-						html.EscapeString(rawMessage.Text),
-						"\n",
-						"<br>",
-					)
-				}
+		} else if len(rawMessage.CaptionEntities) > 0 {
+			post.TextWithMarkup, err = teleblog.FormWebhookTextMarkup(rawMessage.Caption, rawMessage.CaptionEntities)
+			if err != nil {
+				return err
 			}
+		} else {
+			post.TextWithMarkup = strings.ReplaceAll(
+				html.EscapeString(rawMessage.Text),
+				"\n",
+				"<br>",
+			)
+		}
+	}
+
+	// # Links to Telegram posts hosted on the blog
+	post.TextWithMarkup, err = newTelegramPostResolver(app, siteConfig, permalinks).RewriteLinks(post.TextWithMarkup)
+	if err != nil {
+		return err
+	}
+
+	// Extract and fetch link preview
+	if url := extractFirstURL(post.Text); url != "" {
+		if preview, err := fetchLinkPreview(url); err == nil {
+			post.LinkPreview = preview
+		}
+	}
+
+	// # Get comments from group chat
+	chat := teleblog.Chat{}
+
+	err = teleblog.ChatQuery(app.Dao()).Where(
+		dbx.HashExp{
+			"id": post.ChatId,
+		},
+	).Limit(1).One(&chat)
+	if err != nil {
+		// # Posts of removed chats are not shown
+		if strings.Contains(err.Error(), "no rows") {
+			return c.JSON(404, map[string]string{
+				"error": "Post not found",
+			})
 		}
 
-		// # Add quote
-		for _, comment := range comments {
-			if comment.TgReplyToMessageId <= 0 || comment.TgReplyToMessageId == post.TgMessageId {
-				continue
-			}
-
-			for _, repliedTo := range comments {
-				if repliedTo.TgMessageId != comment.TgReplyToMessageId {
-					continue
-				}
+		return err
+	}
 
-				comment.ReplyToComment = &repliedTo.CommentWithTextWithMarkup
-				break
-			}
-		}
+	// # Replies, forwards and series of replies
+	pageData := views.PostPageData{}
 
-		// # Prepare SEO metadata
-		seo := views.SeoMetadata{
-			Title:       post.Title,
-			Description: post.SeoDescription,
-			Image:       "",
-			Url:         fmt.Sprintf("%s%s", app.Settings().Meta.AppUrl, views.GetPostUrl(post.Post)),
-			Type:        "article",
-		}
+	albumPostsModels := []teleblog.Post{post.Post}
+	for _, albumPost := range albumPosts {
+		albumPostsModels = append(albumPostsModels, albumPost.Post)
+	}
 
-		if seo.Title == "" {
-			seo.Title = templu.RemoveNewLines(fmt.Sprintf("%.60s", post.Text))
-		}
+	err = loadPostContextCards(app, siteConfig, permalinks, chat, albumPostsModels, &pageData)
+	if err != nil {
+		return fmt.Errorf("PostPageHandler: get post context error: %w", err)
+	}
 
-		if seo.Description == "" {
-			seo.Description = templu.RemoveNewLines(fmt.Sprintf("%.160s", post.Text))
-		}
+	pageData.Series, err = loadPostSeries(app, siteConfig, permalinks, albumPostsModels)
+	if err != nil {
+		return fmt.Errorf("PostPageHandler: get post series error: %w", err)
+	}
 
-		if len(post.Media) > 0 {
-			seo.Image = fmt.Sprintf("%s%s", app.Settings().Meta.AppUrl, post.Media[0])
+	// # Get comments for post
+	postsIds := []any{post.Id}
+	if len(albumPosts) > 0 {
+		for _, albumPost := range albumPosts {
+			postsIds = append(postsIds, albumPost.Id)
 		}
-
-		// ## Header
-		header := partials.HeaderData{
-			LogoUrl: teleblog.ImagePath(
+	}
+
+	commentsFilters := PostCommentsFilters{}
+
+	if err := c.Bind(&commentsFilters); err != nil {
+		return err
+	}
+
+	comments, commentsPagination, commentsTotal, err := LoadCommentThreads(app, post, postsIds, commentsFilters)
+	if err != nil {
+		return fmt.Errorf("PostPageHandler: get comments error: %w", err)
+	}
+
+	// # Prepare SEO metadata
+	siteUrl := siteConfig.SiteUrl(app.Settings().Meta.AppUrl)
+
+	seo := views.SeoMetadata{
+		Title:       post.Title,
+		Description: post.SeoDescription,
+		Image:       "",
+		Url:         fmt.Sprintf("%s%s", siteUrl, permalinks.PostPath(post.Post)),
+		Type:        "article",
+	}
+
+	if seo.Title == "" {
+		seo.Title = templu.RemoveNewLines(fmt.Sprintf("%.60s", post.Text))
+	}
+
+	if seo.Description == "" {
+		seo.Description = templu.RemoveNewLines(fmt.Sprintf("%.160s", post.Text))
+	}
+
+	if len(post.Media) > 0 {
+		seo.Image = fmt.Sprintf("%s%s", siteUrl, post.Media[0])
+	}
+
+	// ## Header
+	header := partials.HeaderData{
+		LogoUrl: teleblog.ImagePath(
+			siteConfigCollection,
+			&siteConfig.BaseModel,
+			siteConfig.LogoUrl,
+		),
+		LogoAlt:   siteConfig.LogoAlt,
+		MenuItems: []partials.HeaderMenuItem{},
+	}
+
+	for _, item := range menu {
+		header.MenuItems = append(header.MenuItems, partials.HeaderMenuItem{
+			Name: item.Name,
+			Url:  item.Url,
+		})
+	}
+
+	err = setHeaderChannel(app, &header, chat)
+	if err != nil {
+		return err
+	}
+
+	component := views.PostPage(
+		views.BaseLayoutData{
+			Seo:                    seo,
+			YandexMetrikaCounter:   siteConfig.YandexMetrikaCounter,
+			GoogleAnalyticsCounter: siteConfig.GoogleAnalyticsCounter,
+			PrimaryColor:           siteConfig.PrimaryColor,
+			BgImage: teleblog.ImagePath(
 				siteConfigCollection,
 				&siteConfig.BaseModel,
-				siteConfig.LogoUrl,
+				siteConfig.BgImage,
 			),
-			LogoAlt:   siteConfig.LogoAlt,
-			MenuItems: []partials.HeaderMenuItem{},
-		}
-
-		for _, item := range menu {
-			header.MenuItems = append(header.MenuItems, partials.HeaderMenuItem{
-				Name: item.Name,
-				Url:  item.Url,
-			})
-		}
-
-		component := views.PostPage(
-			views.BaseLayoutData{
-				Seo:                    seo,
-				YandexMetrikaCounter:   siteConfig.YandexMetrikaCounter,
-				GoogleAnalyticsCounter: siteConfig.GoogleAnalyticsCounter,
-				PrimaryColor:           siteConfig.PrimaryColor,
-				BgImage: teleblog.ImagePath(
-					siteConfigCollection,
-					&siteConfig.BaseModel,
-					siteConfig.BgImage,
-				),
-				CustomCss: siteConfig.CustomCss,
-				FavIcon: teleblog.ImagePath(
-					siteConfigCollection,
-					&siteConfig.BaseModel,
-					siteConfig.Favicon,
-				),
-				CanonicalUrl: fmt.Sprintf(
-					"%s%s",
-					strings.TrimSuffix(app.Settings().Meta.AppUrl, "/"),
-					views.GetPostUrl(post.Post),
-				),
-			},
-			views.PostPageData{
-				Header: header,
-				Footer: partials.FooterData{
-					Text: siteConfig.Footer,
-				},
+			CustomCss: siteConfig.CustomCss,
+			FavIcon: teleblog.ImagePath(
+				siteConfigCollection,
+				&siteConfig.BaseModel,
+				siteConfig.Favicon,
+			),
+			CanonicalUrl: fmt.Sprintf(
+				"%s%s",
+				siteUrl,
+				permalinks.PostPath(post.Post),
+			),
+		},
+		views.PostPageData{
+			Header: header,
+			Footer: partials.FooterData{
+				Text: siteConfig.Footer,
 			},
-			chat,
-			post,
-			comments,
-		)
-
-		return component.Render(c.Request().Context(), c.Response().Writer)
-	})
+			CommentsTotal:      commentsTotal,
+			CommentsPagination: commentsPagination,
+			ReplyTo:            pageData.ReplyTo,
+			ForwardedFrom:      pageData.ForwardedFrom,
+			Series:             pageData.Series,
+		},
+		chat,
+		post,
+		comments,
+	)
+
+	return component.Render(c.Request().Context(), c.Response().Writer)
 }
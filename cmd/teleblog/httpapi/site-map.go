@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Dionid/teleblog/libs/teleblog"
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
@@ -20,11 +22,23 @@ type SitemapURL struct {
 
 func SiteMapAndRobotsPageHandler(e *core.ServeEvent, app core.App) {
 	e.Router.GET("/robots.txt", func(c echo.Context) error {
-		baseURL := app.Settings().Meta.AppUrl
+		siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(404, map[string]string{
+					"error": "Configuration not found",
+				})
+			}
+
+			return err
+		}
+
+		baseURL := siteConfig.SiteUrl(app.Settings().Meta.AppUrl)
 
 		txt := fmt.Sprintf(`User-agent: *
 Allow: /
 Allow: /post/*
+Allow: /c/*
 Allow: /public/*
 Allow: /sitemap.xml
 
@@ -46,8 +60,21 @@ Host: %s`, baseURL, baseURL)
 
 	// # sitemap.xml
 	e.Router.GET("/sitemap.xml", func(c echo.Context) error {
+		siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(404, map[string]string{
+					"error": "Configuration not found",
+				})
+			}
+
+			return err
+		}
+
 		posts := []teleblog.Post{}
-		err := teleblog.PostQuery(app.Dao()).
+		err = teleblog.PostQuery(app.Dao()).
+			Where(teleblog.TenantPostExp(siteConfig)).
+			AndWhere(dbx.HashExp{"unparsable": false, "hidden": false}).
 			OrderBy("created desc").
 			All(&posts)
 
@@ -55,7 +82,7 @@ Host: %s`, baseURL, baseURL)
 			return err
 		}
 
-		baseURL := app.Settings().Meta.AppUrl
+		baseURL := siteConfig.SiteUrl(app.Settings().Meta.AppUrl)
 		urls := []SitemapURL{
 			{
 				Loc:        baseURL,
@@ -65,13 +92,49 @@ Host: %s`, baseURL, baseURL)
 			},
 		}
 
-		for _, post := range posts {
-			loc := post.Slug
+		// # Channel pages
+		chats := []teleblog.Chat{}
 
-			if loc == "" {
-				loc = post.Id
-			}
+		err = teleblog.TenantChatQuery(app.Dao(), siteConfig).
+			AndWhere(dbx.HashExp{"tg_type": []any{"channel", "privatechannel"}}).
+			AndWhere(dbx.NewExp("tg_username != ''")).
+			All(&chats)
+		if err != nil {
+			return err
+		}
 
+		for _, chat := range chats {
+			urls = append(urls, SitemapURL{
+				Loc:        baseURL + "/c/" + chat.TgUsername,
+				LastMod:    time.Now(),
+				ChangeFreq: "daily",
+				Priority:   "0.9",
+			})
+		}
+
+		// # Static pages
+		pages := []teleblog.Page{}
+
+		err = teleblog.TenantPageQuery(app.Dao(), siteConfig).All(&pages)
+		if err != nil {
+			return err
+		}
+
+		for _, page := range pages {
+			urls = append(urls, SitemapURL{
+				Loc:        baseURL + teleblog.PagePath(page),
+				LastMod:    page.Updated.Time(),
+				ChangeFreq: "monthly",
+				Priority:   "0.7",
+			})
+		}
+
+		permalinks, err := teleblog.NewPermalinks(app.Dao(), siteConfig)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
 			changeFreq := "yearly"
 
 			if post.Created.Time().AddDate(0, 0, 7).Before(time.Now()) {
@@ -79,7 +142,7 @@ Host: %s`, baseURL, baseURL)
 			}
 
 			urls = append(urls, SitemapURL{
-				Loc:        baseURL + "/post/" + loc,
+				Loc:        baseURL + permalinks.PostPath(post),
 				LastMod:    post.Updated.Time(),
 				ChangeFreq: changeFreq,
 				Priority:   "0.8",
@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// telegramPostResolver finds posts of the site by username
+// of the channel and id of the message in it
+type telegramPostResolver struct {
+	app        core.App
+	siteConfig *teleblog.Config
+	permalinks *teleblog.Permalinks
+	// # "channel/tg post id" -> permalink, empty if post is not on the site
+	resolved map[string]string
+}
+
+func newTelegramPostResolver(app core.App, siteConfig *teleblog.Config, permalinks *teleblog.Permalinks) *telegramPostResolver {
+	return &telegramPostResolver{
+		app:        app,
+		siteConfig: siteConfig,
+		permalinks: permalinks,
+		resolved:   map[string]string{},
+	}
+}
+
+// Resolve returns permalink of the post or empty string if it is not on the site
+func (r *telegramPostResolver) Resolve(channel string, tgPostId int) (string, error) {
+	key := strings.ToLower(channel) + "/" + strconv.Itoa(tgPostId)
+
+	if path, ok := r.resolved[key]; ok {
+		return path, nil
+	}
+
+	post := teleblog.Post{}
+
+	err := teleblog.PostQuery(r.app.Dao()).
+		InnerJoin("chat", dbx.NewExp("chat.id = post.chat_id")).
+		Where(dbx.NewExp("chat.tg_username = {:channel} COLLATE NOCASE", dbx.Params{"channel": channel})).
+		AndWhere(dbx.HashExp{"post.tg_post_id": tgPostId}).
+		AndWhere(dbx.NewExp("post.unparsable = false AND post.hidden = false")).
+		AndWhere(teleblog.TenantPostExp(r.siteConfig)).
+		Limit(1).
+		One(&post)
+	if err != nil && !strings.Contains(err.Error(), "no rows") {
+		return "", fmt.Errorf("telegramPostResolver: get post error: %w", err)
+	}
+
+	path := ""
+
+	if err == nil {
+		path = r.permalinks.PostPath(post)
+	}
+
+	r.resolved[key] = path
+
+	return path, nil
+}
+
+// RewriteLinks replaces links to Telegram posts of the site with their permalinks
+func (r *telegramPostResolver) RewriteLinks(markup string) (string, error) {
+	return teleblog.RewriteTelegramPostLinks(markup, r.Resolve)
+}
+
+// TelegramLinkHandler redirects from Telegram post links (/t/channel/123)
+// to the post on the site or to Telegram if the post is not on the site
+func TelegramLinkHandler(e *core.ServeEvent, app core.App) {
+	e.Router.GET("/t/:channel/:id", func(c echo.Context) error {
+		tgPostId, err := strconv.Atoi(c.PathParam("id"))
+		if err != nil {
+			return c.JSON(404, map[string]string{
+				"error": "Post not found",
+			})
+		}
+
+		channel := c.PathParam("channel")
+
+		// # Config of the site on this host
+		siteConfig, err := teleblog.FindTenantConfig(app.Dao(), c.Request().Host)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				return c.JSON(404, map[string]string{
+					"error": "Configuration not found",
+				})
+			}
+
+			return err
+		}
+
+		permalinks, err := teleblog.NewPermalinks(app.Dao(), siteConfig)
+		if err != nil {
+			return err
+		}
+
+		path, err := newTelegramPostResolver(app, siteConfig, permalinks).Resolve(channel, tgPostId)
+		if err != nil {
+			return err
+		}
+
+		// # Post can be published later, so redirects are temporary
+		if path == "" {
+			return c.Redirect(http.StatusFound, teleblog.TelegramPostUrl(channel, tgPostId))
+		}
+
+		return c.Redirect(http.StatusFound, path)
+	})
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/Dionid/teleblog/libs/teleblog"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"gopkg.in/telebot.v4"
+)
+
+// # Bot username is requested once
+var botUsernameCache = struct {
+	sync.Mutex
+	username string
+}{}
+
+func botUsername(token string) (string, error) {
+	botUsernameCache.Lock()
+	defer botUsernameCache.Unlock()
+
+	if botUsernameCache.username != "" {
+		return botUsernameCache.username, nil
+	}
+
+	b, err := telebot.NewBot(telebot.Settings{
+		Token: token,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	botUsernameCache.username = b.Me.Username
+
+	return botUsernameCache.username, nil
+}
+
+// VerificationLinkHandler gives signed in user a deep link that binds
+// Telegram account in one tap
+func VerificationLinkHandler(config Config, e *core.ServeEvent, app core.App) {
+	e.Router.POST("/api/teleblog/verification-link", func(c echo.Context) error {
+		authRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+		if authRecord == nil {
+			return apis.NewUnauthorizedError("", nil)
+		}
+
+		username, err := botUsername(config.TelegramBotToken)
+		if err != nil {
+			return apis.NewBadRequestError("Can't get bot info", err)
+		}
+
+		token, err := teleblog.NewTgVerificationToken(app.Dao(), authRecord.Id)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"link":    teleblog.TgVerificationDeepLink(username, token),
+			"expires": token.Created.Time().Add(config.VerificationTokenTTL),
+		})
+	}, apis.RequireRecordAuth("users"))
+}
@@ -9,9 +9,9 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
-	"fmt"
-	"github.com/Dionid/teleblog/libs/teleblog"
 	"html/template"
+	"path"
+	"strings"
 )
 
 var StyleTemplate = template.Must(template.New("example").Parse("<style>{{ . }}</style>"))
@@ -20,12 +20,18 @@ func EscapedCssComponent(content string) templ.Component {
 	return templ.FromGoHTML(StyleTemplate, template.CSS(templ.EscapeString(content)))
 }
 
-func GetPostUrl(post teleblog.Post) string {
-	if post.Slug != "" {
-		return fmt.Sprintf("/post/%s", post.Slug)
+// MediaKind returns how media file must be rendered: "video", "audio", "image" or "file"
+func MediaKind(media string) string {
+	switch strings.ToLower(path.Ext(media)) {
+	case ".mp4", ".mov", ".webm":
+		return "video"
+	case ".ogg", ".oga", ".mp3", ".m4a", ".wav":
+		return "audio"
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic":
+		return "image"
+	default:
+		return "file"
 	}
-
-	return fmt.Sprintf("/post/%s", post.Id)
 }
 
 var _ = templruntime.GeneratedTemplate
@@ -11,6 +11,7 @@ import (
 
 	"github.com/Dionid/teleblog/cmd/teleblog/admin"
 	"github.com/Dionid/teleblog/cmd/teleblog/botapi"
+	"github.com/Dionid/teleblog/cmd/teleblog/features"
 	"github.com/Dionid/teleblog/cmd/teleblog/httpapi"
 	_ "github.com/Dionid/teleblog/cmd/teleblog/pb_migrations"
 	"github.com/pocketbase/pocketbase"
@@ -61,11 +62,26 @@ func main() {
 
 	// # API
 	httpapi.InitApi(httpapi.Config{
-		Env: config.Env,
+		Env:                  config.Env,
+		TelegramBotToken:     config.TelegramBotToken,
+		VerificationTokenTTL: config.VerificationTokenTTL,
 	}, app, gctx)
 
 	// # Init additional commands
-	AdditionalCommands(app)
+	AdditionalCommands(app, config)
+
+	// # Comments moderation
+	features.InitCommentModeration(app)
+	features.InitSpamClassifier(app, config.SpamThreshold)
+
+	// # Posts written in the admin panel
+	features.InitWebPosts(app)
+
+	// # Unique slugs and redirects from the old ones
+	features.InitPostSlugs(app)
+
+	// # Permalink patterns of sites
+	features.InitPermalinks(app)
 
 	// # Init
 	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
@@ -77,6 +93,12 @@ func main() {
 			return fmt.Errorf("failed to initialize upload history UI: %w", err)
 		}
 
+		// # Posts and comments moderation
+		err = admin.InitModerationUI(app, e)
+		if err != nil {
+			return fmt.Errorf("failed to initialize moderation UI: %w", err)
+		}
+
 		// # Bot
 		if !config.DisableBot {
 			pref := telebot.Settings{
@@ -85,6 +107,7 @@ func main() {
 				Poller:  &telebot.LongPoller{Timeout: 60 * time.Second, AllowedUpdates: telebot.AllowedUpdates},
 				OnError: func(err error, c telebot.Context) {
 					app.Logger().Error("Error in bot", "error:", err)
+					botapi.NotifyUpdateError(app, err, c)
 				},
 				Synchronous: true,
 			}
@@ -94,7 +117,16 @@ func main() {
 				return fmt.Errorf("failed to create bot: %w", err)
 			}
 
-			err = botapi.InitBotCommands(b, app)
+			features.InitNotifications(app, b, config.NotificationInterval)
+			features.InitTagSubscriptions(app, b)
+			features.InitScheduledPosts(app, b, config.SchedulerInterval)
+
+			scheduleLocation, err := time.LoadLocation(config.ScheduleTimezone)
+			if err != nil {
+				return fmt.Errorf("failed to load schedule timezone: %w", err)
+			}
+
+			err = botapi.InitBotCommands(b, app, config.VerificationTokenTTL, scheduleLocation)
 			if err != nil && !strings.Contains(err.Error(), "retry after") {
 				return fmt.Errorf("Init bot commands error: %s", err)
 			}
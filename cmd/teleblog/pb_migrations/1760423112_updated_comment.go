@@ -0,0 +1,57 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_media := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "q8wzk3ne",
+			"name": "media",
+			"type": "file",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"mimeTypes": [],
+				"thumbs": [],
+				"maxSelect": 99,
+				"maxSize": 5242880,
+				"protected": false
+			}
+		}`), new_media); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_media)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("q8wzk3ne")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,70 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_hidden := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "hd7rm2xq",
+			"name": "hidden",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_hidden); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_hidden)
+
+		// add
+		new_filtered := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "fl3kz9wp",
+			"name": "filtered",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_filtered); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_filtered)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("hd7rm2xq")
+
+		// remove
+		collection.Schema.RemoveField("fl3kz9wp")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,114 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_hide_bot_comments := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "hb5qcn1t",
+			"name": "hide_bot_comments",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_hide_bot_comments); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_hide_bot_comments)
+
+		// add
+		new_comment_blocked_words := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "bw8ye4jd",
+			"name": "comment_blocked_words",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_comment_blocked_words); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_comment_blocked_words)
+
+		// add
+		new_comment_blocked_regexps := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "br2vu6sm",
+			"name": "comment_blocked_regexps",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_comment_blocked_regexps); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_comment_blocked_regexps)
+
+		// add
+		new_comment_blocked_tg_user_ids := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "bu9ha7lx",
+			"name": "comment_blocked_tg_user_ids",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_comment_blocked_tg_user_ids); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_comment_blocked_tg_user_ids)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("hb5qcn1t")
+
+		// remove
+		collection.Schema.RemoveField("bw8ye4jd")
+
+		// remove
+		collection.Schema.RemoveField("br2vu6sm")
+
+		// remove
+		collection.Schema.RemoveField("bu9ha7lx")
+
+		return dao.SaveCollection(collection)
+	})
+}
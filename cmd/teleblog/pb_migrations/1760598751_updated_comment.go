@@ -0,0 +1,99 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_spam_label := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sp4lbq8e",
+			"name": "spam_label",
+			"type": "select",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSelect": 1,
+				"values": [
+					"spam",
+					"ham"
+				]
+			}
+		}`), new_spam_label); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_spam_label)
+
+		// add
+		new_spam_score := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sc6nx2vo",
+			"name": "spam_score",
+			"type": "number",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"noDecimal": false
+			}
+		}`), new_spam_score); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_spam_score)
+
+		// add
+		new_spam := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sm1fj5ka",
+			"name": "spam",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_spam); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_spam)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("f7ecawbcx0paa90")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("sp4lbq8e")
+
+		// remove
+		collection.Schema.RemoveField("sc6nx2vo")
+
+		// remove
+		collection.Schema.RemoveField("sm1fj5ka")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,107 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_tg_title := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "tt2gw7rd",
+			"name": "tg_title",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_tg_title); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_title)
+
+		// add
+		new_tg_photo := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "tp9ck3mz",
+			"name": "tg_photo",
+			"type": "file",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"mimeTypes": [
+					"image/jpeg",
+					"image/png",
+					"image/webp"
+				],
+				"thumbs": [],
+				"maxSelect": 1,
+				"maxSize": 5242880,
+				"protected": false
+			}
+		}`), new_tg_photo); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_photo)
+
+		// add
+		new_tg_photo_unique_id := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "tu5ef8nb",
+			"name": "tg_photo_unique_id",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_tg_photo_unique_id); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_photo_unique_id)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("tt2gw7rd")
+
+		// remove
+		collection.Schema.RemoveField("tp9ck3mz")
+
+		// remove
+		collection.Schema.RemoveField("tu5ef8nb")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,90 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_config_domain` + "`" + ` ON ` + "`" + `config` + "`" + ` (` + "`" + `domain` + "`" + `) WHERE ` + "`" + `domain` + "`" + ` != ''"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		// add
+		new_domain := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "dm6wq1hx",
+			"name": "domain",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_domain); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_domain)
+
+		// add
+		new_user_id := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "us3nv8ck",
+			"name": "user_id",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "_pb_users_auth_",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_user_id); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_user_id)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("dm6wq1hx")
+
+		// remove
+		collection.Schema.RemoveField("us3nv8ck")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,57 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("ltd548lkltrvx4b")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_config_id := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "cf4jt0ra",
+			"name": "config_id",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "g5axsrp0qjo62t9",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": 1,
+				"displayFields": null
+			}
+		}`), new_config_id); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_config_id)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("ltd548lkltrvx4b")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("cf4jt0ra")
+
+		return dao.SaveCollection(collection)
+	})
+}
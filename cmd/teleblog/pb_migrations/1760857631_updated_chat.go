@@ -0,0 +1,55 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_tg_description := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "td8oy2gs",
+			"name": "tg_description",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_tg_description); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_description)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("td8oy2gs")
+
+		return dao.SaveCollection(collection)
+	})
+}
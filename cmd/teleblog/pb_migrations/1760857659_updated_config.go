@@ -0,0 +1,57 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_homepage_chat_ids := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "hc7pw3dz",
+			"name": "homepage_chat_ids",
+			"type": "relation",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"collectionId": "s1q7t7ofpbuozf9",
+				"cascadeDelete": false,
+				"minSelect": null,
+				"maxSelect": null,
+				"displayFields": null
+			}
+		}`), new_homepage_chat_ids); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_homepage_chat_ids)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("hc7pw3dz")
+
+		return dao.SaveCollection(collection)
+	})
+}
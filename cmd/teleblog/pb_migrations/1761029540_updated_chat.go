@@ -0,0 +1,74 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_tg_post_gaps := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "pg4vd8mk",
+			"name": "tg_post_gaps",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_tg_post_gaps); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_post_gaps)
+
+		// add
+		new_tg_skipped_post_ids := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sk2jx7qa",
+			"name": "tg_skipped_post_ids",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_tg_skipped_post_ids); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_skipped_post_ids)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("s1q7t7ofpbuozf9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("pg4vd8mk")
+
+		// remove
+		collection.Schema.RemoveField("sk2jx7qa")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,197 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		jsonData := `{
+			"id": "sc7pq2mx9vb4ke3",
+			"created": "2025-10-23 07:10:10.000Z",
+			"updated": "2025-10-23 07:10:10.000Z",
+			"name": "scheduled_post",
+			"type": "base",
+			"system": false,
+			"schema": [
+				{
+					"system": false,
+					"id": "sp1usr4k",
+					"name": "user_id",
+					"type": "relation",
+					"required": true,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"collectionId": "_pb_users_auth_",
+						"cascadeDelete": true,
+						"minSelect": null,
+						"maxSelect": 1,
+						"displayFields": null
+					}
+				},
+				{
+					"system": false,
+					"id": "sp2cht7m",
+					"name": "chat_id",
+					"type": "relation",
+					"required": true,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"collectionId": "s1q7t7ofpbuozf9",
+						"cascadeDelete": true,
+						"minSelect": null,
+						"maxSelect": 1,
+						"displayFields": null
+					}
+				},
+				{
+					"system": false,
+					"id": "sp3txt9q",
+					"name": "text",
+					"type": "text",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"min": null,
+						"max": null,
+						"pattern": ""
+					}
+				},
+				{
+					"system": false,
+					"id": "sp4med2w",
+					"name": "media",
+					"type": "file",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"mimeTypes": [],
+						"thumbs": [],
+						"maxSelect": 10,
+						"maxSize": 52428800,
+						"protected": false
+					}
+				},
+				{
+					"system": false,
+					"id": "sp5pub6r",
+					"name": "publish_at",
+					"type": "date",
+					"required": true,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"min": "",
+						"max": ""
+					}
+				},
+				{
+					"system": false,
+					"id": "sp6sts1x",
+					"name": "status",
+					"type": "select",
+					"required": true,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"maxSelect": 1,
+						"values": [
+							"scheduled",
+							"published",
+							"failed",
+							"canceled"
+						]
+					}
+				},
+				{
+					"system": false,
+					"id": "sp7err3n",
+					"name": "error",
+					"type": "text",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"min": null,
+						"max": null,
+						"pattern": ""
+					}
+				},
+				{
+					"system": false,
+					"id": "sp8src5c",
+					"name": "tg_source_chat_id",
+					"type": "number",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"min": null,
+						"max": null,
+						"noDecimal": true
+					}
+				},
+				{
+					"system": false,
+					"id": "sp9msg8i",
+					"name": "tg_source_message_ids",
+					"type": "json",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"maxSize": 2000000
+					}
+				},
+				{
+					"system": false,
+					"id": "spatpi1d",
+					"name": "tg_post_id",
+					"type": "number",
+					"required": false,
+					"presentable": false,
+					"unique": false,
+					"options": {
+						"min": null,
+						"max": null,
+						"noDecimal": true
+					}
+				}
+			],
+			"indexes": [
+				"CREATE INDEX ` + "`" + `idx_scheduled_post_status` + "`" + ` ON ` + "`" + `scheduled_post` + "`" + ` (\n  ` + "`" + `status` + "`" + `,\n  ` + "`" + `publish_at` + "`" + `\n)"
+			],
+			"listRule": null,
+			"viewRule": null,
+			"createRule": null,
+			"updateRule": null,
+			"deleteRule": null,
+			"options": {}
+		}`
+
+		collection := &models.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), &collection); err != nil {
+			return err
+		}
+
+		return daos.New(db).SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sc7pq2mx9vb4ke3")
+		if err != nil {
+			return err
+		}
+
+		return dao.DeleteCollection(collection)
+	})
+}
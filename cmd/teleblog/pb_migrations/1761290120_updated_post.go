@@ -0,0 +1,108 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_markdown := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "wmd3kx7p",
+			"name": "markdown",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_markdown); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_markdown)
+
+		// add
+		new_cover := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "wcv8qn2f",
+			"name": "cover",
+			"type": "file",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"mimeTypes": [
+					"image/jpeg",
+					"image/png",
+					"image/gif",
+					"image/webp"
+				],
+				"thumbs": [],
+				"maxSelect": 1,
+				"maxSize": 5242880,
+				"protected": false
+			}
+		}`), new_cover); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_cover)
+
+		// add
+		new_tags := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "wtg5hr1m",
+			"name": "tags",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_tags); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tags)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("wmd3kx7p")
+
+		// remove
+		collection.Schema.RemoveField("wcv8qn2f")
+
+		// remove
+		collection.Schema.RemoveField("wtg5hr1m")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,51 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_hidden := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "phd4n7xq",
+			"name": "hidden",
+			"type": "bool",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {}
+		}`), new_hidden); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_hidden)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("phd4n7xq")
+
+		return dao.SaveCollection(collection)
+	})
+}
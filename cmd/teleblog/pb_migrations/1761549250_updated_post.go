@@ -0,0 +1,55 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_unparsable_reason := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "pur6m2wk",
+			"name": "unparsable_reason",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_unparsable_reason); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_unparsable_reason)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("pur6m2wk")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,56 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		// # The oldest post keeps duplicated slug, others get their id appended
+		if _, err := db.NewQuery(`
+			UPDATE post SET slug = slug || '-' || id
+			WHERE slug != '' AND EXISTS (
+				SELECT 1 FROM post older
+				WHERE older.slug = post.slug
+				AND (older.created < post.created OR (older.created = post.created AND older.id < post.id))
+			)
+		`).Execute(); err != nil {
+			return err
+		}
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n)",
+			"CREATE UNIQUE INDEX ` + "`" + `idx_post_slug` + "`" + ` ON ` + "`" + `post` + "`" + ` (` + "`" + `slug` + "`" + `) WHERE ` + "`" + `slug` + "`" + ` != ''"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n)"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,76 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		// add
+		new_permalink_pattern := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "pl8wr3kq",
+			"name": "permalink_pattern",
+			"type": "text",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"pattern": ""
+			}
+		}`), new_permalink_pattern); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_permalink_pattern)
+
+		// add
+		new_old_permalink_patterns := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "pl5hn2vx",
+			"name": "old_permalink_patterns",
+			"type": "json",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSize": 2000000
+			}
+		}`), new_old_permalink_patterns); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_old_permalink_patterns)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("g5axsrp0qjo62t9")
+		if err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("pl8wr3kq")
+
+		// remove
+		collection.Schema.RemoveField("pl5hn2vx")
+
+		return dao.SaveCollection(collection)
+	})
+}
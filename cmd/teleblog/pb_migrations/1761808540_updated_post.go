@@ -0,0 +1,86 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n)",
+			"CREATE UNIQUE INDEX ` + "`" + `idx_post_slug` + "`" + ` ON ` + "`" + `post` + "`" + ` (` + "`" + `slug` + "`" + `) WHERE ` + "`" + `slug` + "`" + ` != ''",
+			"CREATE INDEX ` + "`" + `idx_post_reply_to` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_reply_to_post_id` + "`" + `\n)"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		// add
+		new_tg_reply_to_post_id := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "rp3tq8zn",
+			"name": "tg_reply_to_post_id",
+			"type": "number",
+			"required": false,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"min": null,
+				"max": null,
+				"noDecimal": true
+			}
+		}`), new_tg_reply_to_post_id); err != nil {
+			return err
+		}
+		collection.Schema.AddField(new_tg_reply_to_post_id)
+
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+
+		// # Replies of existing posts are taken from their raw messages
+		_, err = db.NewQuery(`
+			UPDATE post SET tg_reply_to_post_id = COALESCE(
+				CASE WHEN is_tg_history_message
+					THEN json_extract(tg_message_raw, '$.reply_to_message_id')
+					ELSE json_extract(tg_message_raw, '$.reply_to_message.message_id')
+				END,
+				0
+			)
+			WHERE is_tg_message = true AND json_valid(tg_message_raw)
+		`).Execute()
+
+		return err
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n)",
+			"CREATE UNIQUE INDEX ` + "`" + `idx_post_slug` + "`" + ` ON ` + "`" + `post` + "`" + ` (` + "`" + `slug` + "`" + `) WHERE ` + "`" + `slug` + "`" + ` != ''"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		// remove
+		collection.Schema.RemoveField("rp3tq8zn")
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -0,0 +1,47 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n) WHERE ` + "`" + `tg_post_id` + "`" + ` > 0",
+			"CREATE UNIQUE INDEX ` + "`" + `idx_post_slug` + "`" + ` ON ` + "`" + `post` + "`" + ` (` + "`" + `slug` + "`" + `) WHERE ` + "`" + `slug` + "`" + ` != ''",
+			"CREATE INDEX ` + "`" + `idx_post_reply_to` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_reply_to_post_id` + "`" + `\n)"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("52sylu6udk1kc6r")
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`[
+			"CREATE UNIQUE INDEX ` + "`" + `idx_2MmrwEN` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_post_id` + "`" + `\n)",
+			"CREATE UNIQUE INDEX ` + "`" + `idx_post_slug` + "`" + ` ON ` + "`" + `post` + "`" + ` (` + "`" + `slug` + "`" + `) WHERE ` + "`" + `slug` + "`" + ` != ''",
+			"CREATE INDEX ` + "`" + `idx_post_reply_to` + "`" + ` ON ` + "`" + `post` + "`" + ` (\n  ` + "`" + `chat_id` + "`" + `,\n  ` + "`" + `tg_reply_to_post_id` + "`" + `\n)"
+		]`), &collection.Indexes); err != nil {
+			return err
+		}
+
+		return dao.SaveCollection(collection)
+	})
+}
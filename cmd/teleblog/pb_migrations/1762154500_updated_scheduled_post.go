@@ -0,0 +1,81 @@
+package pb_migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sc7pq2mx9vb4ke3")
+		if err != nil {
+			return err
+		}
+
+		// update
+		edit_status := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sp6sts1x",
+			"name": "status",
+			"type": "select",
+			"required": true,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSelect": 1,
+				"values": [
+					"scheduled",
+					"publishing",
+					"published",
+					"failed",
+					"canceled"
+				]
+			}
+		}`), edit_status); err != nil {
+			return err
+		}
+		collection.Schema.AddField(edit_status)
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db);
+
+		collection, err := dao.FindCollectionByNameOrId("sc7pq2mx9vb4ke3")
+		if err != nil {
+			return err
+		}
+
+		// update
+		edit_status := &schema.SchemaField{}
+		if err := json.Unmarshal([]byte(`{
+			"system": false,
+			"id": "sp6sts1x",
+			"name": "status",
+			"type": "select",
+			"required": true,
+			"presentable": false,
+			"unique": false,
+			"options": {
+				"maxSelect": 1,
+				"values": [
+					"scheduled",
+					"published",
+					"failed",
+					"canceled"
+				]
+			}
+		}`), edit_status); err != nil {
+			return err
+		}
+		collection.Schema.AddField(edit_status)
+
+		return dao.SaveCollection(collection)
+	})
+}
@@ -2,15 +2,10 @@ package main
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/Dionid/teleblog/cmd/teleblog/features"
 	"github.com/Dionid/teleblog/libs/teleblog"
-	"github.com/google/uuid"
-	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
-	"github.com/pocketbase/pocketbase/tools/security"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func prepareDB(app *pocketbase.PocketBase, config *Config) error {
@@ -40,112 +35,29 @@ func prepareDB(app *pocketbase.PocketBase, config *Config) error {
 		}
 	}
 
-	// # Fix comments without posta
-	commentsWithoutPost := make([]teleblog.Comment, 0)
-	err = teleblog.CommentQuery(app.Dao()).
-		Where(
-			dbx.NewExp(
-				`post_id = ""`,
-			),
-		).
-		AndWhere(
-			dbx.NewExp(
-				"tg_reply_to_message_id > 0",
-			),
-		).
-		AndWhere(
-			dbx.NewExp(
-				"is_tg_history_message = false",
-			),
-		).
-		All(&commentsWithoutPost)
+	// # Link comments without post
+	relinkResult, err := features.RelinkAllComments(app)
 	if err != nil {
-		return fmt.Errorf("Query comments without post error: %w", err)
+		return fmt.Errorf("Relink comments error: %w", err)
 	}
 
-	app.Logger().Warn("Found comments without post", "count", len(commentsWithoutPost))
-
-	// TODO: Uncomment this block when ready to fix comments without posts
-	// for _, comment := range commentsWithoutPost {
-	// 	jb, err := comment.TgMessageRaw.MarshalJSON()
-	// 	if err != nil {
-	// 		app.Logger().Error("PrepareDB: marshal comment error", "error", err)
-	// 		continue
-	// 	}
-
-	// 	rawMessage := telebot.Message{}
-
-	// 	err = json.Unmarshal(jb, &rawMessage)
-	// 	if err != nil {
-	// 		app.Logger().Error("PrepareDB: unmarshal comment error", "error", err)
-	// 		continue
-	// 	}
-
-	// 	if rawMessage.ReplyTo != nil && rawMessage.ReplyTo.OriginalMessageID > 0 {
-	// 		// Find the post by tg_reply_to_message_id
-	// 		post := teleblog.Post{}
-
-	// 		err := teleblog.PostQuery(app.Dao()).
-	// 			Where(dbx.HashExp{"tg_message_id": rawMessage.ReplyTo.OriginalMessageID}).
-	// 			One(&post)
-	// 		if err != nil {
-	// 			if strings.Contains(err.Error(), "no rows in result set") {
-	// 				continue // Post not found, skip this comment
-	// 			}
-	// 			return fmt.Errorf("Query post by tg_reply_to_message_id error: %w", err)
-	// 		}
-
-	// 		comment.PostId = post.Id
-	// 		if err := app.Dao().Save(&comment); err != nil {
-	// 			return fmt.Errorf("Failed to update comment with post ID: %w", err)
-	// 		}
-
-	// 		post.TgGroupMessageId = rawMessage.ThreadID
-	// 		if err := app.Dao().Save(&post); err != nil {
-	// 			return fmt.Errorf("Failed to update post with tg_group_message_id: %w", err)
-	// 		}
+	if len(relinkResult.Unresolved) > 0 {
+		app.Logger().Warn("Found comments without post", "linked", relinkResult.Linked, "unresolved", len(relinkResult.Unresolved))
+	}
 
-	// 		app.Logger().Info("Updated comment with post ID", "comment_id", comment.Id, "post_id", post.Id)
-	// 	}
-	// }
+	// # Posts missed while bot was down
+	if _, err := features.DetectAllPostGaps(app); err != nil {
+		return fmt.Errorf("Detect post gaps error: %w", err)
+	}
 
-	// # Prepare users
-	user := teleblog.User{}
-	err = teleblog.UserQuery(app.Dao()).
-		Limit(1).
-		One(&user)
+	// # Owner is created by init command
+	total, err := app.Dao().TotalAdmins()
 	if err != nil {
-		if !strings.Contains(err.Error(), "no rows") {
-			return err
-		}
-
-		// hash the password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(
-			config.TelegramBotToken[0:9],
-		), 12)
-		if err != nil {
-			return err
-		}
-
-		user = teleblog.User{
-			Username:     "default",
-			PasswordHash: string(hashedPassword),
-			TokenKey:     security.RandomString(50),
-		}
-		if err := app.Dao().Save(&user); err != nil {
-			return fmt.Errorf("failed to create default user: %w", err)
-		}
-
-		tgToken := teleblog.TgVerificationToken{
-			UserId:   user.Id,
-			Value:    uuid.New().String(),
-			Verified: false,
-		}
-		if err := app.Dao().Save(&tgToken); err != nil {
-			return fmt.Errorf("failed to create default verification token: %w", err)
-		}
+		return fmt.Errorf("Count admins error: %w", err)
+	}
 
-		app.Logger().Info("Created default user and verification token", "user_id", user.Id, "token", tgToken.Value)
+	if total == 0 {
+		app.Logger().Warn("No admins found, run `teleblog init ADMIN_EMAIL` to create admin and owner user")
 	}
 
 	return nil
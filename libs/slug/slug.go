@@ -4,34 +4,54 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
-var transliterations = map[string]string{
-	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d",
-	"е": "e", "ё": "yo", "ж": "zh", "з": "z", "и": "i",
-	"й": "y", "к": "k", "л": "l", "м": "m", "н": "n",
-	"о": "o", "п": "p", "р": "r", "с": "s", "т": "t",
-	"у": "u", "ф": "f", "х": "h", "ц": "ts", "ч": "ch",
-	"ш": "sh", "щ": "sch", "ъ": "", "ы": "y", "ь": "",
-	"э": "e", "ю": "yu", "я": "ya",
-	"А": "a", "Б": "b", "В": "v", "Г": "g", "Д": "d",
-	"Е": "e", "Ё": "yo", "Ж": "zh", "З": "z", "И": "i",
-	"Й": "y", "К": "k", "Л": "l", "М": "m", "Н": "n",
-	"О": "o", "П": "p", "Р": "r", "С": "s", "Т": "t",
-	"У": "u", "Ф": "f", "Х": "h", "Ц": "ts", "Ч": "ch",
-	"Ш": "sh", "Щ": "sch", "Ъ": "", "Ы": "y", "Ь": "",
-	"Э": "e", "Ю": "yu", "Я": "ya",
+var transliterations = map[rune]string{
+	// # Russian
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+	'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+	// # Ukrainian and Belarusian
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+	// # Kazakh
+	'ә': "a", 'ғ': "g", 'қ': "k", 'ң': "ng", 'ө': "o",
+	'ұ': "u", 'ү': "u", 'һ': "h",
+	// # Latin letters without diacritics decomposition
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l",
+	'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
 }
 
+var nonSlugCharsRegexp = regexp.MustCompile("[^a-z0-9]+")
+
 func transliterate(text string) string {
-	for cyrillic, latin := range transliterations {
-		text = strings.ReplaceAll(text, cyrillic, latin)
+	var result strings.Builder
+
+	for _, r := range norm.NFC.String(strings.ToLower(text)) {
+		if latin, ok := transliterations[r]; ok {
+			result.WriteString(latin)
+			continue
+		}
+
+		// # Diacritics are split from Latin letters and dropped (é -> e)
+		for _, decomposed := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, decomposed) {
+				result.WriteRune(decomposed)
+			}
+		}
 	}
-	return text
+
+	return result.String()
 }
 
 func GenerateSlug(text string, t time.Time) string {
-	// Transliterate Russian characters to Latin
+	// Transliterate Cyrillic and Latin letters with diacritics
 	text = transliterate(text)
 
 	// Take first 100 chars to create meaningful slug
@@ -43,8 +63,7 @@ func GenerateSlug(text string, t time.Time) string {
 	text = strings.ToLower(text)
 
 	// Replace special characters
-	reg := regexp.MustCompile("[^a-z0-9]+")
-	text = reg.ReplaceAllString(text, "-")
+	text = nonSlugCharsRegexp.ReplaceAllString(text, "-")
 
 	// Remove leading/trailing hyphens
 	text = strings.Trim(text, "-")
@@ -53,6 +72,11 @@ func GenerateSlug(text string, t time.Time) string {
 		text = text[:90]
 	}
 
+	// Date of the post, not of the slug generation
+	if t.IsZero() {
+		t = time.Now()
+	}
+
 	// Append timestamp
 	text = text + "-" + t.Format("2006-01-02")
 
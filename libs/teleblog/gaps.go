@@ -0,0 +1,90 @@
+package teleblog
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PostGap is a range of channel message ids without posts
+type PostGap struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+func (g PostGap) String() string {
+	if g.From == g.To {
+		return fmt.Sprintf("%d", g.From)
+	}
+
+	return fmt.Sprintf("%d–%d", g.From, g.To)
+}
+
+// FormatPostGaps returns human readable list of gaps
+func FormatPostGaps(gaps []PostGap) string {
+	items := make([]string, 0, len(gaps))
+
+	for _, gap := range gaps {
+		items = append(items, gap.String())
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// FindPostGaps finds ranges between known message ids, which are
+// neither posts nor skipped (service or deleted) messages.
+// Channel message ids are sequential, so any such range was missed.
+func FindPostGaps(postIds []int, skippedIds []int) []PostGap {
+	ids := make([]int, 0, len(postIds)+len(skippedIds))
+	ids = append(ids, postIds...)
+	ids = append(ids, skippedIds...)
+
+	sort.Ints(ids)
+
+	gaps := []PostGap{}
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i]-ids[i-1] > 1 {
+			gaps = append(gaps, PostGap{From: ids[i-1] + 1, To: ids[i] - 1})
+		}
+	}
+
+	return gaps
+}
+
+// NewPostGaps returns gaps which are not covered by previous ones
+func NewPostGaps(previous []PostGap, current []PostGap) []PostGap {
+	newGaps := []PostGap{}
+
+	for _, gap := range current {
+		known := false
+
+		for _, prev := range previous {
+			if gap.From >= prev.From && gap.To <= prev.To {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			newGaps = append(newGaps, gap)
+		}
+	}
+
+	return newGaps
+}
+
+// KnownPostGaps returns current gaps which are covered by previous ones
+func KnownPostGaps(previous []PostGap, current []PostGap) []PostGap {
+	newGaps := NewPostGaps(previous, current)
+	knownGaps := []PostGap{}
+
+	for _, gap := range current {
+		if !slices.Contains(newGaps, gap) {
+			knownGaps = append(knownGaps, gap)
+		}
+	}
+
+	return knownGaps
+}
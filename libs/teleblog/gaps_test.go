@@ -0,0 +1,100 @@
+package teleblog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPostGaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		postIds    []int
+		skippedIds []int
+		gaps       []PostGap
+	}{
+		{"no posts", nil, nil, []PostGap{}},
+		{"sequential", []int{1, 2, 3}, nil, []PostGap{}},
+		{"one missing", []int{1, 3}, nil, []PostGap{{From: 2, To: 2}}},
+		{"range missing", []int{1, 5}, nil, []PostGap{{From: 2, To: 4}}},
+		{"unsorted", []int{7, 1, 3}, nil, []PostGap{{From: 2, To: 2}, {From: 4, To: 6}}},
+		{"skipped fill gaps", []int{1, 5}, []int{2, 3, 4}, []PostGap{}},
+		{"skipped split gap", []int{1, 6}, []int{3}, []PostGap{{From: 2, To: 2}, {From: 4, To: 5}}},
+		{"duplicates", []int{1, 1, 2}, []int{2}, []PostGap{}},
+	}
+
+	for _, test := range tests {
+		gaps := FindPostGaps(test.postIds, test.skippedIds)
+
+		if !reflect.DeepEqual(gaps, test.gaps) {
+			t.Errorf("%s: FindPostGaps() = %v, want %v", test.name, gaps, test.gaps)
+		}
+	}
+}
+
+func TestNewPostGaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []PostGap
+		current  []PostGap
+		newGaps  []PostGap
+		known    []PostGap
+	}{
+		{
+			"no previous",
+			nil,
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{},
+		},
+		{
+			"same",
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{},
+			[]PostGap{{From: 2, To: 4}},
+		},
+		{
+			"partly filled",
+			[]PostGap{{From: 2, To: 8}},
+			[]PostGap{{From: 2, To: 3}, {From: 6, To: 8}},
+			[]PostGap{},
+			[]PostGap{{From: 2, To: 3}, {From: 6, To: 8}},
+		},
+		{
+			"grown",
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{{From: 2, To: 5}, {From: 10, To: 10}},
+			[]PostGap{{From: 2, To: 5}, {From: 10, To: 10}},
+			[]PostGap{},
+		},
+		{
+			"filled",
+			[]PostGap{{From: 2, To: 4}},
+			[]PostGap{},
+			[]PostGap{},
+			[]PostGap{},
+		},
+	}
+
+	for _, test := range tests {
+		newGaps := NewPostGaps(test.previous, test.current)
+
+		if !reflect.DeepEqual(newGaps, test.newGaps) {
+			t.Errorf("%s: NewPostGaps() = %v, want %v", test.name, newGaps, test.newGaps)
+		}
+
+		known := KnownPostGaps(test.previous, test.current)
+
+		if !reflect.DeepEqual(known, test.known) {
+			t.Errorf("%s: KnownPostGaps() = %v, want %v", test.name, known, test.known)
+		}
+	}
+}
+
+func TestFormatPostGaps(t *testing.T) {
+	formatted := FormatPostGaps([]PostGap{{From: 2, To: 2}, {From: 4, To: 6}})
+
+	if formatted != "2, 4–6" {
+		t.Errorf("FormatPostGaps() = %q", formatted)
+	}
+}
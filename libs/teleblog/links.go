@@ -0,0 +1,53 @@
+package teleblog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// # href='https://t.me/channel/123?single', also telegram.me and t.me/s/ links
+var telegramPostHrefRegexp = regexp.MustCompile(`href=(['"])https?://(?:t|telegram)\.me/(?:s/)?([A-Za-z0-9_]+)/([0-9]+)[^'"]*['"]`)
+
+// TelegramPostUrl returns link to the channel post in Telegram
+func TelegramPostUrl(channel string, tgPostId int) string {
+	return fmt.Sprintf("https://t.me/%s/%d", channel, tgPostId)
+}
+
+// RewriteTelegramPostLinks replaces links to Telegram channel posts in
+// the markup with paths returned by resolve, links resolved to empty
+// path are kept
+func RewriteTelegramPostLinks(markup string, resolve func(channel string, tgPostId int) (string, error)) (string, error) {
+	var resolveErr error
+
+	result := telegramPostHrefRegexp.ReplaceAllStringFunc(markup, func(href string) string {
+		if resolveErr != nil {
+			return href
+		}
+
+		match := telegramPostHrefRegexp.FindStringSubmatch(href)
+
+		tgPostId, err := strconv.Atoi(match[3])
+		if err != nil {
+			return href
+		}
+
+		path, err := resolve(match[2], tgPostId)
+		if err != nil {
+			resolveErr = err
+			return href
+		}
+
+		if path == "" {
+			return href
+		}
+
+		return "href=" + match[1] + path + match[1]
+	})
+
+	if resolveErr != nil {
+		return markup, fmt.Errorf("RewriteTelegramPostLinks: resolve error: %w", resolveErr)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package teleblog
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkHtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// # Raw HTML in markdown is not rendered, so posts can't break the page
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(goldmarkHtml.WithHardWraps()),
+)
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// IsWebPost checks if post was written in the admin panel, not in Telegram
+func (m *Post) IsWebPost() bool {
+	return !m.IsTgMessage && !m.IsTgHistoryMessage
+}
+
+// MarkdownToHtml renders markdown of the web post
+func MarkdownToHtml(source string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// MarkdownToText returns plain text of markdown for search, previews and SEO
+func MarkdownToText(source string) (string, error) {
+	markup, err := MarkdownToHtml(source)
+	if err != nil {
+		return "", err
+	}
+
+	text := htmlTagRegexp.ReplaceAllString(markup, "")
+
+	return strings.TrimSpace(html.UnescapeString(text)), nil
+}
+
+// ParseWebPostTags splits tags of the web post (e.g. "golang, #pocketbase")
+func ParseWebPostTags(rawTags string) []string {
+	tags := []string{}
+	known := map[string]bool{}
+
+	for _, rawTag := range strings.FieldsFunc(rawTags, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	}) {
+		value, err := CorrectTagValue("#" + strings.TrimLeft(rawTag, "#"))
+		if err != nil || known[value] {
+			continue
+		}
+
+		known[value] = true
+		tags = append(tags, value)
+	}
+
+	return tags
+}
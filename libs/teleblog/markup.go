@@ -1,6 +1,8 @@
 package teleblog
 
 import (
+	"encoding/json"
+	"fmt"
 	"html"
 	"slices"
 	"sort"
@@ -171,3 +173,48 @@ func FormWebhookTextMarkup(srcText string, entities telebot.Entities) (string, e
 
 	return newLineResultText, nil
 }
+
+// PostTextWithMarkup renders text of the post from its raw message,
+// empty result means post has no formatting and plain text can be shown
+func PostTextWithMarkup(post Post) (string, error) {
+	if post.IsWebPost() {
+		return MarkdownToHtml(post.Markdown)
+	}
+
+	jb, err := post.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	if post.IsTgHistoryMessage {
+		rawMessage := HistoryMessage{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err != nil {
+			return "", fmt.Errorf("PostTextWithMarkup: unmarshal history message error: %w", err)
+		}
+
+		if len(rawMessage.Text.Items) > 0 {
+			return FormHistoryRawTextWithMarkup(rawMessage.Text), nil
+		}
+
+		return HistoryTextEntitiesWithToTextWithMarkup(rawMessage.TextEntities), nil
+	}
+
+	rawMessage := telebot.Message{}
+
+	err = json.Unmarshal(jb, &rawMessage)
+	if err != nil {
+		return "", fmt.Errorf("PostTextWithMarkup: unmarshal message error: %w", err)
+	}
+
+	if len(rawMessage.Entities) > 0 {
+		return FormWebhookTextMarkup(rawMessage.Text, rawMessage.Entities)
+	}
+
+	if len(rawMessage.CaptionEntities) > 0 {
+		return FormWebhookTextMarkup(rawMessage.Caption, rawMessage.CaptionEntities)
+	}
+
+	return "", nil
+}
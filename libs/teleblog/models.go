@@ -23,6 +23,8 @@ type User struct {
 
 	TgUserId   int64  `json:"tgUserId" db:"tg_user_id"`
 	TgUsername string `json:"tgUsername" db:"tg_username"`
+
+	NotificationSettings types.JsonMap `json:"notificationSettings" db:"notification_settings"`
 }
 
 func (m *User) TableName() string {
@@ -67,6 +69,22 @@ type Chat struct {
 	TgChatId       int64  `json:"tgChatId" db:"tg_chat_id"`
 	TgType         string `json:"tgType" db:"tg_type"` //  "private" | "group" | "supergroup" | "channel" | "privatechannel"
 	TgLinkedChatId int64  `json:"tgLinkedChatId" db:"tg_linked_chat_id"`
+
+	// # Metadata synced from Telegram
+	TgTitle         string `json:"tgTitle" db:"tg_title"`
+	TgDescription   string `json:"tgDescription" db:"tg_description"`
+	TgPhoto         string `json:"tgPhoto" db:"tg_photo"`
+	TgPhotoUniqueId string `json:"tgPhotoUniqueId" db:"tg_photo_unique_id"`
+
+	// # Comments moderation
+	HideBotComments         bool                    `json:"hideBotComments" db:"hide_bot_comments"`
+	CommentBlockedWords     types.JsonArray[string] `json:"commentBlockedWords" db:"comment_blocked_words"`
+	CommentBlockedRegexps   types.JsonArray[string] `json:"commentBlockedRegexps" db:"comment_blocked_regexps"`
+	CommentBlockedTgUserIds types.JsonArray[int64]  `json:"commentBlockedTgUserIds" db:"comment_blocked_tg_user_ids"`
+
+	// # Missing posts detection
+	TgPostGaps       types.JsonArray[PostGap] `json:"tgPostGaps" db:"tg_post_gaps"`
+	TgSkippedPostIds types.JsonArray[int]     `json:"tgSkippedPostIds" db:"tg_skipped_post_ids"`
 }
 
 func (m *Chat) TableName() string {
@@ -94,6 +112,9 @@ type Post struct {
 	TgGroupMessageId int           `json:"tgGroupMessageId" db:"tg_group_message_id"`
 	TgMessageRaw     types.JsonMap `json:"tgMessageRaw" db:"tg_message_raw"`
 
+	// Id of the channel post this post replies to
+	TgReplyToPostId int `json:"tgReplyToPostId" db:"tg_reply_to_post_id"`
+
 	Media types.JsonArray[string] `json:"media" db:"media"`
 
 	AlbumID string `json:"albumId" db:"album_id"`
@@ -102,7 +123,18 @@ type Post struct {
 	Slug           string `json:"slug" db:"slug"`
 	SeoDescription string `json:"seoDescription" db:"seo_description"`
 
-	Unparsable bool `json:"unparsable" db:"unparsable"`
+	Unparsable       bool   `json:"unparsable" db:"unparsable"`
+	UnparsableReason string `json:"unparsableReason" db:"unparsable_reason"`
+	// Hidden by the owner
+	Hidden bool `json:"hidden" db:"hidden"`
+
+	// # Web posts written in the admin panel
+	Markdown string `json:"markdown" db:"markdown"`
+	Cover    string `json:"cover" db:"cover"`
+	Tags     string `json:"tags" db:"tags"`
+
+	// # Readers already notified about the post by tag subscriptions
+	TagNotifiedTgUserIds types.JsonArray[int64] `json:"tagNotifiedTgUserIds" db:"tag_notified_tg_user_ids"`
 }
 
 func (m *Post) TableName() string {
@@ -129,6 +161,18 @@ type Comment struct {
 	TgMessageId        int           `json:"tgMessageId" db:"tg_comment_id"`
 	TgMessageRaw       types.JsonMap `json:"tgMessageRaw" db:"tg_message_raw"`
 	TgReplyToMessageId int           `json:"tgReplyToMessageId" db:"tg_reply_to_message_id"`
+
+	Media types.JsonArray[string] `json:"media" db:"media"`
+
+	// Hidden by the owner
+	Hidden bool `json:"hidden" db:"hidden"`
+	// Hidden by the chat moderation rules
+	Filtered bool `json:"filtered" db:"filtered"`
+
+	// # Spam
+	SpamLabel string  `json:"spamLabel" db:"spam_label"` // "" | "spam" | "ham" (set by the owner)
+	SpamScore float64 `json:"spamScore" db:"spam_score"`
+	Spam      bool    `json:"spam" db:"spam"`
 }
 
 func (m *Comment) TableName() string {
@@ -177,6 +221,109 @@ func PostTagQuery(dao *daos.Dao) *dbx.SelectQuery {
 	return dao.ModelQuery(&Tag{})
 }
 
+// # PostSlugRedirect
+
+var _ models.Model = (*PostSlugRedirect)(nil)
+
+// PostSlugRedirect is old slug of the post, it is redirected to the new one
+type PostSlugRedirect struct {
+	models.BaseModel
+
+	PostId string `json:"postId" db:"post_id"`
+	Slug   string `json:"slug" db:"slug"`
+}
+
+func (m *PostSlugRedirect) TableName() string {
+	return "post_slug_redirect"
+}
+
+func PostSlugRedirectQuery(dao *daos.Dao) *dbx.SelectQuery {
+	return dao.ModelQuery(&PostSlugRedirect{})
+}
+
+// # RemovedChat
+
+var _ models.Model = (*RemovedChat)(nil)
+
+// RemovedChat is chat removed from the blog with its posts kept,
+// the chat gets the same id when it is added again
+type RemovedChat struct {
+	models.BaseModel
+
+	UserId   string `json:"userId" db:"user_id"`
+	TgChatId int64  `json:"tgChatId" db:"tg_chat_id"`
+	ChatId   string `json:"chatId" db:"chat_id"`
+}
+
+func (m *RemovedChat) TableName() string {
+	return "removed_chat"
+}
+
+func RemovedChatQuery(dao *daos.Dao) *dbx.SelectQuery {
+	return dao.ModelQuery(&RemovedChat{})
+}
+
+// # TagSubscription
+
+var _ models.Model = (*TagSubscription)(nil)
+
+type TagSubscription struct {
+	models.BaseModel
+
+	TgUserId   int64  `json:"tgUserId" db:"tg_user_id"`
+	TgUsername string `json:"tgUsername" db:"tg_username"`
+	TagId      string `json:"tagId" db:"tag_id"`
+}
+
+func (m *TagSubscription) TableName() string {
+	return "tag_subscription"
+}
+
+func TagSubscriptionQuery(dao *daos.Dao) *dbx.SelectQuery {
+	return dao.ModelQuery(&TagSubscription{})
+}
+
+// # ScheduledPost
+
+const (
+	SCHEDULED_POST_STATUS_SCHEDULED = "scheduled"
+	// Post is being sent, it is not sent again even if saving the result fails
+	SCHEDULED_POST_STATUS_PUBLISHING = "publishing"
+	SCHEDULED_POST_STATUS_PUBLISHED  = "published"
+	SCHEDULED_POST_STATUS_FAILED     = "failed"
+	SCHEDULED_POST_STATUS_CANCELED   = "canceled"
+)
+
+var _ models.Model = (*ScheduledPost)(nil)
+
+type ScheduledPost struct {
+	models.BaseModel
+
+	UserId string `json:"userId" db:"user_id"`
+	ChatId string `json:"chatId" db:"chat_id"`
+
+	Text  string                  `json:"text" db:"text"`
+	Media types.JsonArray[string] `json:"media" db:"media"`
+
+	PublishAt types.DateTime `json:"publishAt" db:"publish_at"`
+	Status    string         `json:"status" db:"status"`
+	Error     string         `json:"error" db:"error"`
+
+	// # Draft sent to the bot, it is copied to the channel as is
+	TgSourceChatId     int64                `json:"tgSourceChatId" db:"tg_source_chat_id"`
+	TgSourceMessageIds types.JsonArray[int] `json:"tgSourceMessageIds" db:"tg_source_message_ids"`
+
+	TgPostId int `json:"tgPostId" db:"tg_post_id"`
+}
+
+func (m *ScheduledPost) TableName() string {
+	return "scheduled_post"
+}
+
+func ScheduledPostQuery(dao *daos.Dao) *dbx.SelectQuery {
+	return dao.ModelQuery(&ScheduledPost{})
+}
+
 // # Config
 
 var _ models.Model = (*Config)(nil)
@@ -184,6 +331,18 @@ var _ models.Model = (*Config)(nil)
 type Config struct {
 	models.BaseModel
 
+	// # Tenant
+	Domain string `json:"domain" db:"domain"`  // host of the site, empty for the default site
+	UserId string `json:"userId" db:"user_id"` // owner of the channels, empty for all channels
+
+	// Channels shown on the homepage, empty for all channels
+	HomepageChatIds types.JsonArray[string] `json:"homepageChatIds" db:"homepage_chat_ids"`
+
+	// Pattern of post URLs, e.g. /:year/:month/:slug, empty for /post/:slug
+	PermalinkPattern string `json:"permalinkPattern" db:"permalink_pattern"`
+	// Previous patterns, their links are redirected to the current one
+	OldPermalinkPatterns types.JsonArray[string] `json:"oldPermalinkPatterns" db:"old_permalink_patterns"`
+
 	Description    string `json:"description" db:"description"`
 	SeoTitle       string `json:"seoTitle" db:"seo_title"`
 	SeoDescription string `json:"seoDescription" db:"seo_description"`
@@ -222,9 +381,12 @@ var _ models.Model = (*MenuItem)(nil)
 type MenuItem struct {
 	models.BaseModel
 
+	ConfigId string `json:"configId" db:"config_id"`
 	Name     string `json:"name" db:"name"`
 	Url      string `json:"url" db:"url"`
 	Position int    `json:"position" db:"position"`
+	// # Internal page, used instead of url
+	PageId string `json:"pageId" db:"page_id"`
 }
 
 func (m *MenuItem) TableName() string {
@@ -234,3 +396,27 @@ func (m *MenuItem) TableName() string {
 func MenuItemQuery(dao *daos.Dao) *dbx.SelectQuery {
 	return dao.ModelQuery(&MenuItem{})
 }
+
+// # Page
+
+var _ models.Model = (*Page)(nil)
+
+type Page struct {
+	models.BaseModel
+
+	ConfigId       string `json:"configId" db:"config_id"`
+	Slug           string `json:"slug" db:"slug"`
+	Title          string `json:"title" db:"title"`
+	Markdown       string `json:"markdown" db:"markdown"`
+	SeoTitle       string `json:"seoTitle" db:"seo_title"`
+	SeoDescription string `json:"seoDescription" db:"seo_description"`
+	Published      bool   `json:"published" db:"published"`
+}
+
+func (m *Page) TableName() string {
+	return "page"
+}
+
+func PageQuery(dao *daos.Dao) *dbx.SelectQuery {
+	return dao.ModelQuery(&Page{})
+}
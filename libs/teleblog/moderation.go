@@ -0,0 +1,162 @@
+package teleblog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"gopkg.in/telebot.v4"
+)
+
+// VisibleCommentExp filters out comments hidden by the owner, moderation rules or as spam
+func VisibleCommentExp() dbx.Expression {
+	return dbx.NewExp("comment.hidden = false AND comment.filtered = false AND comment.spam = false")
+}
+
+// CommentModeration is compiled moderation rules of the chat
+type CommentModeration struct {
+	hideBots  bool
+	words     []string
+	regexps   []*regexp.Regexp
+	tgUserIds map[int64]bool
+}
+
+func NewCommentModeration(chat *Chat) (*CommentModeration, error) {
+	moderation := &CommentModeration{
+		hideBots:  chat.HideBotComments,
+		tgUserIds: map[int64]bool{},
+	}
+
+	for _, word := range chat.CommentBlockedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+
+		moderation.words = append(moderation.words, word)
+	}
+
+	for _, expr := range chat.CommentBlockedRegexps {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("NewCommentModeration: invalid regexp %q: %w", expr, err)
+		}
+
+		moderation.regexps = append(moderation.regexps, re)
+	}
+
+	for _, id := range chat.CommentBlockedTgUserIds {
+		moderation.tgUserIds[id] = true
+	}
+
+	return moderation, nil
+}
+
+// CommentAuthor returns Telegram id of the comment author and if it is a bot
+func CommentAuthor(comment *Comment) (int64, bool) {
+	jb, err := comment.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return 0, false
+	}
+
+	if comment.IsTgHistoryMessage {
+		rawMessage := HistoryMessage{}
+
+		if err := json.Unmarshal(jb, &rawMessage); err != nil {
+			return 0, false
+		}
+
+		// # Channels has "channel" prefix and will not match any user id
+		id, err := strconv.ParseInt(strings.TrimPrefix(rawMessage.FromId, "user"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return id, false
+	}
+
+	rawMessage := telebot.Message{}
+
+	if err := json.Unmarshal(jb, &rawMessage); err != nil || rawMessage.Sender == nil {
+		return 0, false
+	}
+
+	// # Messages on behalf of chats (e.g. linked channel) are sent by service bots
+	if rawMessage.SenderChat != nil {
+		return rawMessage.SenderChat.ID, false
+	}
+
+	return rawMessage.Sender.ID, rawMessage.Sender.IsBot
+}
+
+// IsFiltered checks if comment must be hidden by moderation rules
+func (m *CommentModeration) IsFiltered(comment *Comment) bool {
+	authorId, isBot := CommentAuthor(comment)
+
+	if m.hideBots && isBot {
+		return true
+	}
+
+	if authorId != 0 && m.tgUserIds[authorId] {
+		return true
+	}
+
+	text := strings.ToLower(comment.Text)
+
+	for _, word := range m.words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+
+	for _, re := range m.regexps {
+		if re.MatchString(comment.Text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ApplyCommentModeration recalculates filtered flag of all chat comments
+func ApplyCommentModeration(dao *daos.Dao, chat *Chat) error {
+	moderation, err := NewCommentModeration(chat)
+	if err != nil {
+		return err
+	}
+
+	comments := []*Comment{}
+
+	err = CommentQuery(dao).
+		Where(dbx.HashExp{"chat_id": chat.Id}).
+		All(&comments)
+	if err != nil {
+		return fmt.Errorf("ApplyCommentModeration: get comments error: %w", err)
+	}
+
+	for _, comment := range comments {
+		filtered := moderation.IsFiltered(comment)
+		if filtered == comment.Filtered {
+			continue
+		}
+
+		_, err := dao.DB().Update(
+			comment.TableName(),
+			dbx.Params{"filtered": filtered},
+			dbx.HashExp{"id": comment.Id},
+		).Execute()
+		if err != nil {
+			return fmt.Errorf("ApplyCommentModeration: update comment error: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package teleblog
+
+// # Kinds of owner notifications
+const (
+	NOTIFICATION_POST_FAILED      = "post_failed"
+	NOTIFICATION_UNPARSABLE_POST  = "unparsable_post"
+	NOTIFICATION_MEDIA_FAILED     = "media_failed"
+	NOTIFICATION_HISTORY_IMPORTED = "history_imported"
+	NOTIFICATION_NEW_COMMENTS     = "new_comments"
+	NOTIFICATION_POST_GAPS        = "post_gaps"
+)
+
+var NotificationKinds = []string{
+	NOTIFICATION_POST_FAILED,
+	NOTIFICATION_UNPARSABLE_POST,
+	NOTIFICATION_MEDIA_FAILED,
+	NOTIFICATION_HISTORY_IMPORTED,
+	NOTIFICATION_NEW_COMMENTS,
+	NOTIFICATION_POST_GAPS,
+}
+
+// IsNotificationKind checks if kind is known
+func IsNotificationKind(kind string) bool {
+	for _, k := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotificationEnabled checks user settings, all kinds except
+// new comments summaries are enabled by default
+func (m *User) NotificationEnabled(kind string) bool {
+	if enabled, ok := m.NotificationSettings[kind].(bool); ok {
+		return enabled
+	}
+
+	return kind != NOTIFICATION_NEW_COMMENTS
+}
+
+// SetNotificationEnabled changes user settings of the kind
+func (m *User) SetNotificationEnabled(kind string, enabled bool) {
+	if m.NotificationSettings == nil {
+		m.NotificationSettings = map[string]any{}
+	}
+
+	m.NotificationSettings[kind] = enabled
+}
@@ -0,0 +1,64 @@
+package teleblog
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// PagePath returns path of the static page on the site
+func PagePath(page Page) string {
+	return "/" + page.Slug
+}
+
+// TenantMenuItems returns menu of the tenant, items linked to pages
+// get their urls (and are skipped if the page is not published)
+func TenantMenuItems(dao *daos.Dao, config *Config) ([]MenuItem, error) {
+	menu := []MenuItem{}
+
+	err := TenantMenuItemQuery(dao, config).OrderBy("position").All(&menu)
+	if err != nil {
+		return nil, err
+	}
+
+	pageIds := []any{}
+	for _, item := range menu {
+		if item.PageId != "" {
+			pageIds = append(pageIds, item.PageId)
+		}
+	}
+
+	if len(pageIds) == 0 {
+		return menu, nil
+	}
+
+	pages := []Page{}
+
+	err = TenantPageQuery(dao, config).
+		AndWhere(dbx.In("id", pageIds...)).
+		All(&pages)
+	if err != nil {
+		return nil, err
+	}
+
+	pagePaths := map[string]string{}
+	for _, page := range pages {
+		pagePaths[page.Id] = PagePath(page)
+	}
+
+	result := []MenuItem{}
+
+	for _, item := range menu {
+		if item.PageId != "" {
+			path, ok := pagePaths[item.PageId]
+			if !ok {
+				continue
+			}
+
+			item.Url = path
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
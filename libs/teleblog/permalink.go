@@ -0,0 +1,245 @@
+package teleblog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// DefaultPermalinkPattern is used when site has no own pattern,
+// its links are redirected to the site pattern
+const DefaultPermalinkPattern = "/post/:slug"
+
+// Placeholders of permalink pattern segments
+const (
+	PermalinkYear     = ":year"
+	PermalinkMonth    = ":month"
+	PermalinkDay      = ":day"
+	PermalinkSlug     = ":slug"
+	PermalinkId       = ":id"
+	PermalinkChannel  = ":channel"
+	PermalinkTgPostId = ":tg_post_id"
+)
+
+var permalinkPlaceholders = []string{
+	PermalinkYear,
+	PermalinkMonth,
+	PermalinkDay,
+	PermalinkSlug,
+	PermalinkId,
+	PermalinkChannel,
+	PermalinkTgPostId,
+}
+
+// # First segments served by other routes
+var reservedPermalinkSegments = []string{"_", "api", "public", "c", "t"}
+
+var staticPermalinkSegmentRegexp = regexp.MustCompile("^[a-z0-9_.-]+$")
+
+func isPermalinkPlaceholder(segment string) bool {
+	for _, placeholder := range permalinkPlaceholders {
+		if segment == placeholder {
+			return true
+		}
+	}
+
+	return false
+}
+
+func permalinkSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// ValidatePermalinkPattern checks that pattern consists of known
+// placeholders and static segments and identifies the post
+func ValidatePermalinkPattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("Permalink pattern must start with /")
+	}
+
+	segments := permalinkSegments(pattern)
+
+	// # One segment paths are static pages
+	if len(segments) < 2 {
+		return fmt.Errorf("Permalink pattern must have at least 2 segments, e.g. /p/:slug")
+	}
+
+	for _, reserved := range reservedPermalinkSegments {
+		if segments[0] == reserved {
+			return fmt.Errorf("Permalink pattern can't start with /%s", reserved)
+		}
+	}
+
+	used := map[string]bool{}
+
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			if !isPermalinkPlaceholder(segment) {
+				return fmt.Errorf("Unknown permalink placeholder %s, use one of: %s", segment, strings.Join(permalinkPlaceholders, ", "))
+			}
+
+			if used[segment] {
+				return fmt.Errorf("Permalink placeholder %s is used twice", segment)
+			}
+
+			used[segment] = true
+
+			continue
+		}
+
+		if !staticPermalinkSegmentRegexp.MatchString(segment) {
+			return fmt.Errorf("Permalink segment %q must contain only lowercase latin letters, digits, _, . and -", segment)
+		}
+	}
+
+	if !used[PermalinkSlug] && !used[PermalinkId] && !used[PermalinkTgPostId] {
+		return fmt.Errorf("Permalink pattern must contain %s, %s or %s", PermalinkSlug, PermalinkId, PermalinkTgPostId)
+	}
+
+	return nil
+}
+
+// MatchPermalink returns values of the pattern placeholders
+// if path matches the pattern
+func MatchPermalink(pattern string, path string) (map[string]string, bool) {
+	patternSegments := permalinkSegments(pattern)
+	pathSegments := permalinkSegments(path)
+
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+
+	for i, segment := range patternSegments {
+		value := pathSegments[i]
+
+		if isPermalinkPlaceholder(segment) {
+			if value == "" {
+				return nil, false
+			}
+
+			params[segment] = value
+
+			continue
+		}
+
+		if segment != value {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// Permalinks builds paths of the site posts by its permalink pattern
+type Permalinks struct {
+	Pattern string
+	// Old patterns of the site, their links are redirected
+	OldPatterns []string
+	// # chat id -> channel username
+	channels map[string]string
+	// # Message ids repeat across channels, so without :channel
+	// # posts of the site with several channels are linked by id
+	ambiguousTgPostIds bool
+}
+
+func NewPermalinks(dao *daos.Dao, config *Config) (*Permalinks, error) {
+	permalinks := &Permalinks{
+		Pattern:     config.PermalinkPattern,
+		OldPatterns: config.OldPermalinkPatterns,
+		channels:    map[string]string{},
+	}
+
+	if permalinks.Pattern == "" {
+		permalinks.Pattern = DefaultPermalinkPattern
+	}
+
+	if strings.Contains(permalinks.Pattern, PermalinkChannel) {
+		chats := []Chat{}
+
+		err := TenantChatQuery(dao, config).All(&chats)
+		if err != nil {
+			return nil, fmt.Errorf("NewPermalinks: get chats error: %w", err)
+		}
+
+		for _, chat := range chats {
+			permalinks.channels[chat.Id] = chat.TgUsername
+		}
+
+		return permalinks, nil
+	}
+
+	if strings.Contains(permalinks.Pattern, PermalinkTgPostId) {
+		channelsCount := 0
+
+		err := TenantChatQuery(dao, config).
+			Select("count(*)").
+			AndWhere(dbx.HashExp{"chat.tg_type": []any{"channel", "privatechannel"}}).
+			Row(&channelsCount)
+		if err != nil {
+			return nil, fmt.Errorf("NewPermalinks: count channels error: %w", err)
+		}
+
+		permalinks.ambiguousTgPostIds = channelsCount > 1
+	}
+
+	return permalinks, nil
+}
+
+// PostPath returns path of the post by the site pattern
+func (p *Permalinks) PostPath(post Post) string {
+	created := post.Created.Time()
+
+	segments := []string{}
+
+	for _, segment := range permalinkSegments(p.Pattern) {
+		switch segment {
+		case PermalinkYear:
+			segment = created.Format("2006")
+		case PermalinkMonth:
+			segment = created.Format("01")
+		case PermalinkDay:
+			segment = created.Format("02")
+		case PermalinkSlug:
+			segment = post.Slug
+			if segment == "" {
+				segment = post.Id
+			}
+		case PermalinkId:
+			segment = post.Id
+		case PermalinkChannel:
+			// # Private channels have no username
+			segment = p.channels[post.ChatId]
+			if segment == "" {
+				segment = post.ChatId
+			}
+		case PermalinkTgPostId:
+			// # Web posts have no Telegram message
+			segment = post.Id
+			if post.TgMessageId != 0 && !p.ambiguousTgPostIds {
+				segment = strconv.Itoa(post.TgMessageId)
+			}
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// Patterns returns site pattern, its old patterns and the default one,
+// in order links are resolved
+func (p *Permalinks) Patterns() []string {
+	patterns := append([]string{p.Pattern}, p.OldPatterns...)
+
+	if p.Pattern != DefaultPermalinkPattern {
+		patterns = append(patterns, DefaultPermalinkPattern)
+	}
+
+	return patterns
+}
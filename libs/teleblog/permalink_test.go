@@ -0,0 +1,193 @@
+package teleblog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestValidatePermalinkPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"/post/:slug", true},
+		{"/:year/:month/:slug", true},
+		{"/:channel/:slug", true},
+		{"/:channel/:tg_post_id", true},
+		{"/p/:tg_post_id", true},
+		{"/p/:id", true},
+		{"/blog/:year/:month/:day/:slug", true},
+		{"post/:slug", false},
+		{"/:slug", false},
+		{"/api/:slug", false},
+		{"/c/:slug", false},
+		{"/p/:title", false},
+		{"/:slug/:slug", false},
+		{"/Posts/:slug", false},
+		{"/:year/:month", false},
+		{"/:channel/:day", false},
+	}
+
+	for _, test := range tests {
+		err := ValidatePermalinkPattern(test.pattern)
+
+		if test.valid && err != nil {
+			t.Errorf("ValidatePermalinkPattern(%q) error: %v", test.pattern, err)
+		}
+
+		if !test.valid && err == nil {
+			t.Errorf("ValidatePermalinkPattern(%q) must return error", test.pattern)
+		}
+	}
+}
+
+func TestMatchPermalink(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  map[string]string
+		ok      bool
+	}{
+		{"/post/:slug", "/post/hello-2024-05-14", map[string]string{":slug": "hello-2024-05-14"}, true},
+		{"/post/:slug", "/post/hello/", map[string]string{":slug": "hello"}, true},
+		{"/post/:slug", "/posts/hello", nil, false},
+		{"/post/:slug", "/post", nil, false},
+		{"/post/:slug", "/post/", nil, false},
+		{"/post/:slug", "/post/hello/world", nil, false},
+		{
+			"/:year/:month/:slug",
+			"/2024/05/hello",
+			map[string]string{":year": "2024", ":month": "05", ":slug": "hello"},
+			true,
+		},
+		{
+			"/:channel/:tg_post_id",
+			"/mychannel/42",
+			map[string]string{":channel": "mychannel", ":tg_post_id": "42"},
+			true,
+		},
+		{"/p/:tg_post_id", "/p/42", map[string]string{":tg_post_id": "42"}, true},
+		{"/p/:tg_post_id", "/t/42", nil, false},
+	}
+
+	for _, test := range tests {
+		params, ok := MatchPermalink(test.pattern, test.path)
+
+		if ok != test.ok {
+			t.Errorf("MatchPermalink(%q, %q) ok = %v, want %v", test.pattern, test.path, ok, test.ok)
+			continue
+		}
+
+		if ok && !reflect.DeepEqual(params, test.params) {
+			t.Errorf("MatchPermalink(%q, %q) = %v, want %v", test.pattern, test.path, params, test.params)
+		}
+	}
+}
+
+func TestPermalinksPostPath(t *testing.T) {
+	created, err := types.ParseDateTime("2024-05-14 10:00:00.000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPost := func(id string, chatId string, slug string, tgMessageId int) Post {
+		post := Post{
+			ChatId:      chatId,
+			Slug:        slug,
+			TgMessageId: tgMessageId,
+		}
+		post.Id = id
+		post.Created = created
+
+		return post
+	}
+
+	channels := map[string]string{"chat1": "mychannel", "chat2": ""}
+
+	tests := []struct {
+		name       string
+		permalinks Permalinks
+		post       Post
+		path       string
+	}{
+		{
+			"slug",
+			Permalinks{Pattern: "/post/:slug"},
+			newPost("post1", "chat1", "hello", 42),
+			"/post/hello",
+		},
+		{
+			"post without slug",
+			Permalinks{Pattern: "/post/:slug"},
+			newPost("post1", "chat1", "", 42),
+			"/post/post1",
+		},
+		{
+			"date",
+			Permalinks{Pattern: "/:year/:month/:day/:slug"},
+			newPost("post1", "chat1", "hello", 42),
+			"/2024/05/14/hello",
+		},
+		{
+			"channel",
+			Permalinks{Pattern: "/:channel/:tg_post_id", channels: channels},
+			newPost("post1", "chat1", "hello", 42),
+			"/mychannel/42",
+		},
+		{
+			"private channel",
+			Permalinks{Pattern: "/:channel/:tg_post_id", channels: channels},
+			newPost("post1", "chat2", "hello", 42),
+			"/chat2/42",
+		},
+		{
+			"web post",
+			Permalinks{Pattern: "/:channel/:tg_post_id", channels: channels},
+			newPost("post1", "chat1", "hello", 0),
+			"/mychannel/post1",
+		},
+		{
+			"tg post id without channel",
+			Permalinks{Pattern: "/p/:tg_post_id"},
+			newPost("post1", "chat1", "hello", 42),
+			"/p/42",
+		},
+		{
+			"tg post id of several channels",
+			Permalinks{Pattern: "/p/:tg_post_id", ambiguousTgPostIds: true},
+			newPost("post1", "chat1", "hello", 42),
+			"/p/post1",
+		},
+	}
+
+	for _, test := range tests {
+		path := test.permalinks.PostPath(test.post)
+
+		if path != test.path {
+			t.Errorf("%s: PostPath() = %q, want %q", test.name, path, test.path)
+		}
+	}
+}
+
+func TestPermalinksPatterns(t *testing.T) {
+	tests := []struct {
+		permalinks Permalinks
+		patterns   []string
+	}{
+		{Permalinks{Pattern: DefaultPermalinkPattern}, []string{DefaultPermalinkPattern}},
+		{
+			Permalinks{Pattern: "/p/:id", OldPatterns: []string{"/:year/:slug"}},
+			[]string{"/p/:id", "/:year/:slug", DefaultPermalinkPattern},
+		},
+	}
+
+	for _, test := range tests {
+		patterns := test.permalinks.Patterns()
+
+		if !reflect.DeepEqual(patterns, test.patterns) {
+			t.Errorf("Patterns() = %v, want %v", patterns, test.patterns)
+		}
+	}
+}
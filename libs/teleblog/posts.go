@@ -0,0 +1,107 @@
+package teleblog
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// PostsFilters are filters of the blog posts list
+type PostsFilters struct {
+	Search string
+	Tag    string
+}
+
+// PostsListQuery selects visible posts of the chats matching filters
+func PostsListQuery(dao *daos.Dao, filters PostsFilters, chatIds ...interface{}) *dbx.SelectQuery {
+	// Query
+	query := PostQuery(dao).
+		LeftJoin(
+			"comment",
+			dbx.And(
+				dbx.NewExp("comment.post_id = post.id"),
+				VisibleCommentExp(),
+			),
+		).
+		Where(
+			dbx.In("post.chat_id", chatIds...),
+		).
+		// to avoid unsupported post types (video, photo, file, etc.)
+		AndWhere(
+			dbx.Or(
+				dbx.NewExp(`post.text != ""`), // THIS IS CRUCIAL
+				dbx.NewExp(`json_array_length(post.media) > 0`),
+			),
+		).
+		AndWhere(
+			dbx.NewExp("post.unparsable = false AND post.hidden = false"),
+		)
+
+	// ## Filters
+
+	if filters.Search != "" {
+		query = query.AndWhere(
+			dbx.Or(
+				dbx.Like("post.text", filters.Search),
+				dbx.Like("comment.text", filters.Search),
+			),
+		)
+	}
+
+	if filters.Tag != "" {
+		query = query.
+			LeftJoin(
+				"post_tag",
+				dbx.NewExp("post_tag.post_id = post.id"),
+			).
+			LeftJoin(
+				"tag",
+				dbx.NewExp("tag.id = post_tag.tag_id"),
+			).
+			AndWhere(
+				dbx.HashExp{"tag.value": filters.Tag},
+			)
+	}
+
+	return query
+}
+
+// UniquePostSlug returns slug not used by other posts,
+// suffix (-2, -3, etc.) is added if it is taken
+func UniquePostSlug(dao *daos.Dao, slug string, postId string) (string, error) {
+	if slug == "" {
+		return slug, nil
+	}
+
+	candidate := slug
+
+	for i := 2; ; i++ {
+		var total int
+
+		err := PostQuery(dao).
+			Select("count(*)").
+			Where(dbx.HashExp{"slug": candidate}).
+			AndWhere(dbx.Not(dbx.HashExp{"id": postId})).
+			Row(&total)
+		if err != nil {
+			return "", fmt.Errorf("UniquePostSlug: count posts error: %w", err)
+		}
+
+		if total == 0 {
+			return candidate, nil
+		}
+
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// PostPath returns default path of the post page on the blog,
+// it is redirected to the permalink of the site (see Permalinks)
+func PostPath(post Post) string {
+	if post.Slug != "" {
+		return fmt.Sprintf("/post/%s", post.Slug)
+	}
+
+	return fmt.Sprintf("/post/%s", post.Id)
+}
@@ -0,0 +1,197 @@
+package teleblog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v4"
+)
+
+// MessageReference is a Telegram message the post replies to or is forwarded from
+type MessageReference struct {
+	// Name of the channel, chat or user
+	Title string
+	// Telegram username of the channel, chat or user, empty if it is private
+	Username string
+	// Id of the message in the channel, 0 if it is unknown
+	TgPostId int
+	Date     time.Time
+}
+
+// TelegramUrl returns link to the message or to the chat in Telegram,
+// empty if it is private
+func (r *MessageReference) TelegramUrl() string {
+	if r.Username == "" {
+		return ""
+	}
+
+	if r.TgPostId == 0 {
+		return "https://t.me/" + r.Username
+	}
+
+	return TelegramPostUrl(r.Username, r.TgPostId)
+}
+
+// PostReferences are messages the post replies to, quotes or is forwarded from
+type PostReferences struct {
+	// Id of the post of the same channel the post replies to
+	ReplyToTgPostId int
+	// Message of other chat the post replies to
+	ExternalReply *MessageReference
+	// Quoted part of the replied message
+	Quote string
+	// Where the post is forwarded from
+	ForwardOrigin *MessageReference
+}
+
+func telebotChatTitle(chat *telebot.Chat) string {
+	if chat.Title != "" {
+		return chat.Title
+	}
+
+	return strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+}
+
+func telebotUserTitle(user *telebot.User) string {
+	title := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if title == "" {
+		title = user.Username
+	}
+
+	return title
+}
+
+// messageOriginReference converts origin of the forwarded or replied message
+func messageOriginReference(origin *telebot.MessageOrigin) *MessageReference {
+	reference := &MessageReference{
+		TgPostId: origin.MessageID,
+	}
+
+	if origin.DateUnixtime != 0 {
+		reference.Date = origin.Time()
+	}
+
+	switch {
+	case origin.Chat != nil:
+		reference.Title = telebotChatTitle(origin.Chat)
+		reference.Username = origin.Chat.Username
+	case origin.SenderChat != nil:
+		reference.Title = telebotChatTitle(origin.SenderChat)
+		reference.Username = origin.SenderChat.Username
+	case origin.Sender != nil:
+		reference.Title = telebotUserTitle(origin.Sender)
+		reference.Username = origin.Sender.Username
+	default:
+		reference.Title = origin.SenderUsername
+	}
+
+	if origin.Signature != "" {
+		reference.Title += " (" + origin.Signature + ")"
+	}
+
+	return reference
+}
+
+// forwardReference returns where the message is forwarded from,
+// forward_origin is used, older forward_* fields are fallback
+func forwardReference(message *telebot.Message) *MessageReference {
+	if message.Origin != nil {
+		return messageOriginReference(message.Origin)
+	}
+
+	reference := &MessageReference{}
+
+	switch {
+	case message.OriginalChat != nil:
+		reference.Title = telebotChatTitle(message.OriginalChat)
+		reference.Username = message.OriginalChat.Username
+		reference.TgPostId = message.OriginalMessageID
+	case message.OriginalSender != nil:
+		reference.Title = telebotUserTitle(message.OriginalSender)
+		reference.Username = message.OriginalSender.Username
+	case message.OriginalSenderName != "":
+		reference.Title = message.OriginalSenderName
+	default:
+		return nil
+	}
+
+	if message.OriginalUnixtime != 0 {
+		reference.Date = time.Unix(int64(message.OriginalUnixtime), 0)
+	}
+
+	return reference
+}
+
+// ParsePostReferences returns references of the post from its raw message,
+// web posts have none
+func ParsePostReferences(post Post) (*PostReferences, error) {
+	references := &PostReferences{}
+
+	if post.IsWebPost() {
+		return references, nil
+	}
+
+	jb, err := post.TgMessageRaw.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	// # History export has only replies of the same channel and forward names
+	if post.IsTgHistoryMessage {
+		rawMessage := HistoryMessage{}
+
+		err = json.Unmarshal(jb, &rawMessage)
+		if err != nil {
+			return nil, fmt.Errorf("ParsePostReferences: unmarshal history message error: %w", err)
+		}
+
+		references.ReplyToTgPostId = rawMessage.ReplyToMessageId
+
+		if rawMessage.ForwardedFrom != nil && *rawMessage.ForwardedFrom != "" {
+			references.ForwardOrigin = &MessageReference{
+				Title: *rawMessage.ForwardedFrom,
+			}
+		}
+
+		return references, nil
+	}
+
+	rawMessage := telebot.Message{}
+
+	err = json.Unmarshal(jb, &rawMessage)
+	if err != nil {
+		return nil, fmt.Errorf("ParsePostReferences: unmarshal message error: %w", err)
+	}
+
+	if rawMessage.ReplyTo != nil {
+		references.ReplyToTgPostId = rawMessage.ReplyTo.ID
+	}
+
+	if rawMessage.ExternalReply != nil {
+		externalReply := &MessageReference{
+			TgPostId: rawMessage.ExternalReply.MessageID,
+		}
+
+		if rawMessage.ExternalReply.Origin != nil {
+			externalReply = messageOriginReference(rawMessage.ExternalReply.Origin)
+		}
+
+		if rawMessage.ExternalReply.Chat != nil {
+			externalReply.Title = telebotChatTitle(rawMessage.ExternalReply.Chat)
+			externalReply.Username = rawMessage.ExternalReply.Chat.Username
+			externalReply.TgPostId = rawMessage.ExternalReply.MessageID
+		}
+
+		references.ExternalReply = externalReply
+	}
+
+	if rawMessage.Quote != nil {
+		references.Quote = rawMessage.Quote.Text
+	}
+
+	references.ForwardOrigin = forwardReference(&rawMessage)
+
+	return references, nil
+}
@@ -0,0 +1,213 @@
+package teleblog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"gopkg.in/telebot.v4"
+)
+
+const (
+	SPAM_LABEL = "spam"
+	HAM_LABEL  = "ham"
+)
+
+// Classifier doesn't score anything until it has seen
+// at least this amount of spam and ham examples
+const spamClassifierMinExamples = 5
+
+var (
+	spamLinkRegexp    = regexp.MustCompile(`(?i)(https?://|www\.|t\.me/)\S+`)
+	spamMentionRegexp = regexp.MustCompile(`@\w+`)
+)
+
+// SpamClassifier is naive Bayes classifier over comment tokens
+type SpamClassifier struct {
+	spamExamples int
+	hamExamples  int
+
+	spamTokens map[string]int
+	hamTokens  map[string]int
+	vocabulary map[string]bool
+
+	spamTokensTotal int
+	hamTokensTotal  int
+}
+
+func NewSpamClassifier() *SpamClassifier {
+	return &SpamClassifier{
+		spamTokens: map[string]int{},
+		hamTokens:  map[string]int{},
+		vocabulary: map[string]bool{},
+	}
+}
+
+// Train adds tokens of one labeled example
+func (c *SpamClassifier) Train(tokens []string, isSpam bool) {
+	if isSpam {
+		c.spamExamples++
+	} else {
+		c.hamExamples++
+	}
+
+	for _, token := range tokens {
+		c.vocabulary[token] = true
+
+		if isSpam {
+			c.spamTokens[token]++
+			c.spamTokensTotal++
+		} else {
+			c.hamTokens[token]++
+			c.hamTokensTotal++
+		}
+	}
+}
+
+// IsTrained checks if classifier has enough examples to score
+func (c *SpamClassifier) IsTrained() bool {
+	return c.spamExamples >= spamClassifierMinExamples && c.hamExamples >= spamClassifierMinExamples
+}
+
+// Score returns probability (from 0 to 1) of tokens to be spam
+func (c *SpamClassifier) Score(tokens []string) float64 {
+	if !c.IsTrained() {
+		return 0
+	}
+
+	vocabularySize := float64(len(c.vocabulary))
+	totalExamples := float64(c.spamExamples + c.hamExamples)
+
+	spamLog := math.Log(float64(c.spamExamples) / totalExamples)
+	hamLog := math.Log(float64(c.hamExamples) / totalExamples)
+
+	for _, token := range tokens {
+		// # Unknown tokens tell nothing
+		if !c.vocabulary[token] {
+			continue
+		}
+
+		// # Laplace smoothing
+		spamLog += math.Log((float64(c.spamTokens[token]) + 1) / (float64(c.spamTokensTotal) + vocabularySize))
+		hamLog += math.Log((float64(c.hamTokens[token]) + 1) / (float64(c.hamTokensTotal) + vocabularySize))
+	}
+
+	return 1 / (1 + math.Exp(hamLog-spamLog))
+}
+
+// CommentSpamTokens extracts words, links and sender features of the comment
+func CommentSpamTokens(comment *Comment) []string {
+	tokens := []string{}
+	text := comment.Text
+
+	// # Links
+	for _, link := range spamLinkRegexp.FindAllString(text, -1) {
+		tokens = append(tokens, "__link__")
+
+		if !strings.Contains(link, "://") {
+			link = "https://" + link
+		}
+
+		if parsed, err := url.Parse(link); err == nil && parsed.Host != "" {
+			tokens = append(tokens, "__domain__:"+strings.ToLower(strings.TrimPrefix(parsed.Host, "www.")))
+		}
+	}
+
+	text = spamLinkRegexp.ReplaceAllString(text, " ")
+
+	// # Mentions
+	for range spamMentionRegexp.FindAllString(text, -1) {
+		tokens = append(tokens, "__mention__")
+	}
+
+	text = spamMentionRegexp.ReplaceAllString(text, " ")
+
+	// # Words
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		if len([]rune(word)) < 2 {
+			continue
+		}
+
+		tokens = append(tokens, word)
+	}
+
+	if len(words) == 0 {
+		tokens = append(tokens, "__no_text__")
+	}
+
+	if len(comment.Media) > 0 {
+		tokens = append(tokens, "__media__")
+	}
+
+	// # Sender
+	if !comment.IsTgHistoryMessage {
+		jb, err := comment.TgMessageRaw.MarshalJSON()
+		if err != nil {
+			return tokens
+		}
+
+		rawMessage := telebot.Message{}
+
+		if err := json.Unmarshal(jb, &rawMessage); err != nil {
+			return tokens
+		}
+
+		if rawMessage.Sender != nil {
+			if rawMessage.Sender.IsBot {
+				tokens = append(tokens, "__sender_bot__")
+			}
+
+			if rawMessage.Sender.Username == "" {
+				tokens = append(tokens, "__sender_no_username__")
+			}
+
+			if rawMessage.Sender.IsPremium {
+				tokens = append(tokens, "__sender_premium__")
+			}
+		}
+
+		if rawMessage.SenderChat != nil {
+			tokens = append(tokens, "__sender_chat__")
+		}
+
+		if rawMessage.Origin != nil {
+			tokens = append(tokens, "__forward__")
+		}
+
+		if rawMessage.ReplyMarkup != nil && len(rawMessage.ReplyMarkup.InlineKeyboard) > 0 {
+			tokens = append(tokens, "__buttons__")
+		}
+	}
+
+	return tokens
+}
+
+// TrainSpamClassifier trains classifier on all comments labeled by owners
+func TrainSpamClassifier(dao *daos.Dao) (*SpamClassifier, error) {
+	classifier := NewSpamClassifier()
+
+	comments := []*Comment{}
+
+	err := CommentQuery(dao).
+		Where(dbx.In("spam_label", SPAM_LABEL, HAM_LABEL)).
+		All(&comments)
+	if err != nil {
+		return nil, fmt.Errorf("TrainSpamClassifier: get labeled comments error: %w", err)
+	}
+
+	for _, comment := range comments {
+		classifier.Train(CommentSpamTokens(comment), comment.SpamLabel == SPAM_LABEL)
+	}
+
+	return classifier, nil
+}
@@ -30,6 +30,10 @@ func CorrectTagValue(rawValue string) (string, error) {
 }
 
 func ExtractTagsFromPost(post Post) ([]string, error) {
+	if post.IsWebPost() {
+		return ParseWebPostTags(post.Tags), nil
+	}
+
 	tags := []string{}
 
 	jb, err := post.TgMessageRaw.MarshalJSON()
@@ -0,0 +1,145 @@
+package teleblog
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+)
+
+// NormalizeHost removes port and trailing dot from the request host
+func NormalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// FindTenantConfig returns config of the site served on the host
+// or the default one (with empty domain)
+func FindTenantConfig(dao *daos.Dao, host string) (*Config, error) {
+	config := &Config{}
+
+	err := ConfigQuery(dao).
+		Where(dbx.HashExp{"domain": NormalizeHost(host)}).
+		Limit(1).
+		One(config)
+	if err == nil {
+		return config, nil
+	}
+
+	if !strings.Contains(err.Error(), "no rows") {
+		return nil, err
+	}
+
+	err = ConfigQuery(dao).
+		Where(dbx.NewExp("domain IS NULL OR domain = ''")).
+		Limit(1).
+		One(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// FindChatTenantConfig returns config of the site showing the chat:
+// the one of the chat owner with own domain or the default one
+func FindChatTenantConfig(dao *daos.Dao, chat *Chat) (*Config, error) {
+	config := &Config{}
+
+	err := ConfigQuery(dao).
+		Where(dbx.HashExp{"user_id": chat.UserId}).
+		AndWhere(dbx.NewExp("domain IS NOT NULL AND domain != ''")).
+		Limit(1).
+		One(config)
+	if err == nil {
+		return config, nil
+	}
+
+	if !strings.Contains(err.Error(), "no rows") {
+		return nil, err
+	}
+
+	return FindTenantConfig(dao, "")
+}
+
+// IsDefaultTenant checks if config is the site without own domain
+func (m *Config) IsDefaultTenant() bool {
+	return m.Domain == ""
+}
+
+// SiteUrl returns base url of the site: app url for the default site,
+// own domain with the scheme of the app url for others
+func (m *Config) SiteUrl(appUrl string) string {
+	appUrl = strings.TrimSuffix(appUrl, "/")
+
+	if m.IsDefaultTenant() {
+		return appUrl
+	}
+
+	scheme := "https"
+	if strings.HasPrefix(appUrl, "http://") {
+		scheme = "http"
+	}
+
+	return scheme + "://" + m.Domain
+}
+
+// TenantChatQuery selects chats of the tenant
+func TenantChatQuery(dao *daos.Dao, config *Config) *dbx.SelectQuery {
+	query := ChatQuery(dao)
+
+	if config.UserId != "" {
+		query = query.Where(dbx.HashExp{"chat.user_id": config.UserId})
+	}
+
+	return query
+}
+
+// TenantPostExp limits posts to the tenant chats,
+// posts of removed chats are never shown
+func TenantPostExp(config *Config) dbx.Expression {
+	if config.UserId == "" {
+		return dbx.NewExp("post.chat_id IN (SELECT tenant_chat.id FROM chat AS tenant_chat)")
+	}
+
+	return dbx.NewExp(
+		"post.chat_id IN (SELECT tenant_chat.id FROM chat AS tenant_chat WHERE tenant_chat.user_id = {:tenantUserId})",
+		dbx.Params{"tenantUserId": config.UserId},
+	)
+}
+
+// TenantMenuItemQuery selects menu items of the tenant (items without
+// config belong to the default site)
+func TenantMenuItemQuery(dao *daos.Dao, config *Config) *dbx.SelectQuery {
+	if config.IsDefaultTenant() {
+		return MenuItemQuery(dao).Where(
+			dbx.Or(
+				dbx.HashExp{"config_id": config.Id},
+				dbx.NewExp("config_id IS NULL OR config_id = ''"),
+			),
+		)
+	}
+
+	return MenuItemQuery(dao).Where(dbx.HashExp{"config_id": config.Id})
+}
+
+// TenantPageQuery selects published pages of the tenant (pages without
+// config belong to the default site)
+func TenantPageQuery(dao *daos.Dao, config *Config) *dbx.SelectQuery {
+	query := PageQuery(dao).Where(dbx.HashExp{"published": true})
+
+	if config.IsDefaultTenant() {
+		return query.AndWhere(
+			dbx.Or(
+				dbx.HashExp{"config_id": config.Id},
+				dbx.NewExp("config_id IS NULL OR config_id = ''"),
+			),
+		)
+	}
+
+	return query.AndWhere(dbx.HashExp{"config_id": config.Id})
+}
@@ -0,0 +1,61 @@
+package teleblog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// NewTgVerificationToken creates single-use token to bind Telegram account
+// to the user
+func NewTgVerificationToken(dao *daos.Dao, userId string) (*TgVerificationToken, error) {
+	token := &TgVerificationToken{
+		UserId: userId,
+		// # Deep link payload allows only A-Z, a-z, 0-9, _ and - (up to 64 chars)
+		Value:    security.RandomString(32),
+		Verified: false,
+	}
+
+	if err := dao.Save(token); err != nil {
+		return nil, fmt.Errorf("NewTgVerificationToken: save token error: %w", err)
+	}
+
+	return token, nil
+}
+
+// TgVerificationDeepLink returns link that opens the bot and sends
+// the token with /start command
+func TgVerificationDeepLink(botUsername string, token *TgVerificationToken) string {
+	return "https://t.me/" + botUsername + "?start=" + token.Value
+}
+
+// IsExpired checks if token lived longer than ttl
+func (m *TgVerificationToken) IsExpired(ttl time.Duration) bool {
+	return m.Created.Time().Add(ttl).Before(time.Now())
+}
+
+// UseTgVerificationToken marks token as verified, returns false
+// if it was already used
+func UseTgVerificationToken(dao *daos.Dao, token *TgVerificationToken) (bool, error) {
+	// # Conditional update, so the same token can't be used twice concurrently
+	result, err := dao.DB().Update(
+		token.TableName(),
+		dbx.Params{"verified": true},
+		dbx.HashExp{"id": token.Id, "verified": false},
+	).Execute()
+	if err != nil {
+		return false, fmt.Errorf("UseTgVerificationToken: update token error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	token.Verified = true
+
+	return affected > 0, nil
+}